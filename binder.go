@@ -0,0 +1,523 @@
+package sqlparams
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// binder walks a ParsedSQL's occurrences in SQL order, collecting driver
+// arguments and, when the template has bind-time expansions, re-rendering the
+// SQL with freshly allocated placeholder indexes.
+type binder struct {
+	ps           ParsedSQL
+	style        ParamStyle
+	resolver     ParamResolver
+	render       bool
+	sql          strings.Builder
+	last         int
+	args         []any
+	sensitive    []bool
+	next         int
+	placeholders map[placeholderKey]string
+	reported     map[Selector]bool
+	checked      map[placeholderKey]bool
+	natives      map[Identifier]Selector
+	errs         []error
+	row          *rowContext
+	window       rowWindow
+	rowCount     int
+}
+
+// placeholderKey identifies placeholders that share driver arguments: by
+// index for indexed styles and by name for named styles, scoped to the row
+// template element they were resolved against.
+type placeholderKey struct {
+	name   Selector
+	spread bool
+	index  int
+	row    int
+}
+
+// rowContext is the row template element currently being rendered.
+type rowContext struct {
+	name  Selector
+	index int
+	value any
+}
+
+// rowWindow limits which row template elements are rendered, so BindBatches
+// can split them across statements. A negative to means all elements.
+type rowWindow struct {
+	from, to int
+}
+
+// binding is a resolved occurrence: its value, the name it is bound under,
+// and the key shared by placeholders that reuse its arguments.
+type binding struct {
+	value any
+	name  Selector
+	key   placeholderKey
+}
+
+func newBinder(ps ParsedSQL, resolver ParamResolver, window rowWindow) *binder {
+	return &binder{
+		ps:           ps,
+		style:        ps.ParamStyle(),
+		resolver:     resolver,
+		render:       ps.dynamic,
+		args:         make([]any, 0, len(ps.occurrences)),
+		placeholders: make(map[placeholderKey]string),
+		reported:     make(map[Selector]bool),
+		checked:      make(map[placeholderKey]bool),
+		natives:      make(map[Identifier]Selector),
+		window:       window,
+	}
+}
+
+// bind binds every occurrence, expanding row templates within window and
+// dropping conditional blocks whose parameter was not supplied.
+func (ps ParsedSQL) bind(resolver ParamResolver, window rowWindow) (b *binder) {
+	b = newBinder(ps, resolver, window)
+	b.bindFragments(ps.bindFragments())
+	return b
+}
+
+// bindFragments returns the fragments to bind. A hand-built ParsedSQL is
+// bound from its occurrences, or from its parameters when it has none.
+func (ps ParsedSQL) bindFragments() (frags []fragment) {
+	if ps.fragments != nil {
+		return ps.fragments
+	}
+	frags, _ = buildFragments(ps.bindTokens(), nil)
+	return frags
+}
+
+// bindTokens returns the occurrences in SQL order. A hand-built ParsedSQL
+// without occurrences is bound from its parameters instead.
+func (ps ParsedSQL) bindTokens() (tokens QueryTokens) {
+	if len(ps.occurrences) == 0 {
+		tokens = make(QueryTokens, len(ps.parameters))
+		for i, p := range ps.parameters {
+			tokens[i] = QueryToken{Name: p.Name, Index: p.Index, Occurrence: 1, Spread: p.Spread}
+		}
+		goto end
+	}
+	tokens = make(QueryTokens, len(ps.occurrences))
+	copy(tokens, ps.occurrences)
+end:
+	return tokens
+}
+
+func (b *binder) bindFragments(frags []fragment) {
+	for _, f := range frags {
+		switch f.kind {
+		case rowsFragment:
+			b.bindRows(f)
+		case conditionalFragment:
+			b.bindConditional(f)
+		default:
+			b.bindToken(f.token)
+		}
+	}
+}
+
+func (b *binder) key(t QueryToken) (key placeholderKey) {
+	switch b.style {
+	case NamedParamStyle:
+		key = placeholderKey{name: t.Name, spread: t.Spread}
+	default:
+		key = placeholderKey{index: t.Index}
+	}
+	return key
+}
+
+// resolve finds the value for t. Inside a row template, names resolve
+// against the current element first and then against the top-level values.
+func (b *binder) resolve(t QueryToken) (bd binding, ok bool) {
+	if b.row != nil {
+		bd.value, ok = lookupSelector(b.row.value, t.Name)
+		if ok {
+			bd.name = Selector(fmt.Sprintf("%s[%d].%s", b.row.name, b.row.index, t.Name))
+			bd.key = b.key(t)
+			bd.key.name = bd.name
+			bd.key.row = b.row.index + 1
+			goto end
+		}
+	}
+	bd.name = t.Name
+	bd.value, ok = b.lookup(t.Name)
+	bd.key = b.key(t)
+	if t.Parent != "" {
+		// A top-level value used inside a row template is shared by all rows
+		bd.key = placeholderKey{name: t.Name, spread: t.Spread, index: -1}
+	}
+end:
+	return bd, ok
+}
+
+// lookup resolves a top-level name with the binder's resolver, recording
+// the resolver's error, if any, once per name.
+func (b *binder) lookup(name Selector) (value any, ok bool) {
+	var err error
+
+	if b.resolver == nil {
+		goto end
+	}
+	value, ok, err = b.resolver.Resolve(name)
+	if err == nil {
+		goto end
+	}
+	value, ok = nil, false
+	if !b.reported[name] {
+		b.errs = append(b.errs, err)
+	}
+	b.reported[name] = true
+end:
+	return value, ok
+}
+
+func (b *binder) reportMissing(name Selector) {
+	if !b.reported[name] {
+		b.errs = append(b.errs, NewErr(ErrMissingParameter, "name", name))
+	}
+	b.reported[name] = true
+}
+
+func (b *binder) bindToken(t QueryToken) {
+	var values []any
+	var names []Selector
+	var texts []string
+	var text string
+	var c constraint
+	var failed bool
+
+	bd, ok := b.resolve(t)
+	if !ok && t.Optional && !b.reported[bd.name] {
+		bd.value, ok = t.Default, true
+	}
+	if !ok {
+		b.reportMissing(bd.name)
+		goto end
+	}
+
+	if b.style != PositionalParamStyle {
+		text, ok = b.placeholders[bd.key]
+		if ok {
+			goto end
+		}
+	}
+
+	values = []any{bd.value}
+	if t.Spread {
+		values, ok = b.spreadValues(bd)
+		if !ok {
+			goto end
+		}
+	}
+
+	names = make([]Selector, len(values))
+	for i, v := range values {
+		names[i] = bd.name
+		if t.Spread {
+			names[i] = Selector(fmt.Sprintf("%s[%d]", bd.name, i))
+		}
+		if t.Transforms != "" {
+			v, ok = b.transform(t, names[i], v)
+			if !ok {
+				// Coercing or checking the failed output would only add
+				// errors about a value that was never produced
+				failed = true
+				continue
+			}
+		}
+		if t.DataType != "" {
+			v = b.coerce(t, names[i], v)
+		}
+		values[i] = v
+	}
+
+	c, ok = b.ps.constraints[t.Name]
+	if ok && !failed && !b.checked[bd.key] {
+		b.checked[bd.key] = true
+		if t.Spread {
+			b.checkConstraint(t, bd.name, c, values)
+		} else {
+			b.checkConstraint(t, bd.name, c, values[0])
+		}
+	}
+
+	texts = make([]string, len(values))
+	for i, v := range values {
+		texts[i] = b.bindArg(t, names[i], v)
+	}
+	text = strings.Join(texts, ", ")
+	b.placeholders[bd.key] = text
+end:
+	b.write(t, text)
+}
+
+// transform applies t's transforms in order to a value bound under name,
+// recording an ErrTransformFailed and returning false when one fails.
+func (b *binder) transform(t QueryToken, name Selector, value any) (out any, ok bool) {
+	var err error
+	out = value
+	for _, tn := range t.Transforms.Names() {
+		fn, ok := lookupTransform(tn)
+		if !ok {
+			continue
+		}
+		out, err = fn(out)
+		if err != nil && t.Sensitive {
+			// err can quote the value
+			err = NewErr(ErrTransformFailed, "name", name, "transform", tn)
+		}
+		if err != nil {
+			b.errs = append(b.errs, NewErr(
+				ErrTransformFailed,
+				"name", name,
+				"transform", tn,
+				err,
+			))
+			goto end
+		}
+	}
+	ok = true
+end:
+	return out, ok
+}
+
+// coerce converts a value bound under name to t's declared data type,
+// recording an ErrInvalidDataType when it cannot.
+func (b *binder) coerce(t QueryToken, name Selector, value any) any {
+	out, reason := coerceValue(value, t.DataType)
+	if reason != "" {
+		b.errs = append(b.errs, NewErr(
+			ErrInvalidDataType,
+			"name", name,
+			"data_type", t.DataType,
+			"value_kind", fmt.Sprintf("%T", value),
+			"reason", reason,
+		))
+	}
+	return out
+}
+
+// checkConstraint records an ErrConstraintViolation for every constraint
+// the value bound under name violates, redacting the values of sensitive
+// parameters.
+func (b *binder) checkConstraint(t QueryToken, name Selector, c constraint, value any) {
+	for _, v := range c.check(value) {
+		if t.Sensitive {
+			v.value = RedactedValue
+		}
+		b.errs = append(b.errs, NewErr(
+			ErrConstraintViolation,
+			"name", name,
+			"constraint", v.constraint,
+			"limit", v.limit,
+			"value", v.value,
+		))
+	}
+}
+
+// bindRows renders the row template f once per element of its slice value,
+// binding the template's children against each element.
+func (b *binder) bindRows(f fragment) {
+	var elements []any
+	var from, to int
+
+	t := f.token
+	value, ok := b.lookup(t.Name)
+	if !ok {
+		b.reportMissing(t.Name)
+		goto end
+	}
+	elements, ok = sliceValues(value)
+	if !ok {
+		b.errs = append(b.errs, NewErr(
+			ErrInvalidSpreadValue,
+			"name", t.Name,
+			"value_kind", fmt.Sprintf("%T", value),
+		))
+		goto end
+	}
+	if len(elements) == 0 {
+		b.errs = append(b.errs, NewErr(ErrEmptySpreadValue, "name", t.Name))
+		goto end
+	}
+
+	b.rowCount = len(elements)
+	from, to = b.window.from, b.window.to
+	if to < 0 || to > len(elements) {
+		to = len(elements)
+	}
+	b.writeTo(t.Start)
+	for k := from; k < to; k++ {
+		if k > from {
+			b.sql.WriteString(", ")
+		}
+		b.sql.WriteByte('(')
+		b.row = &rowContext{name: t.Name, index: k, value: elements[k]}
+		b.last = f.bodyStart
+		b.bindFragments(f.children)
+		b.writeTo(f.bodyEnd)
+		b.sql.WriteByte(')')
+	}
+	b.row = nil
+	b.last = t.End
+end:
+	return
+}
+
+// bindConditional keeps the body of a conditional block, without its
+// markers, when the block's parameter was supplied, and drops the whole
+// block otherwise.
+func (b *binder) bindConditional(f fragment) {
+	b.writeTo(f.start)
+	b.last = f.end
+	if !b.supplied(f.token) {
+		return
+	}
+	b.last = f.bodyStart
+	b.bindFragments(f.children)
+	b.writeTo(f.bodyEnd)
+	b.last = f.end
+}
+
+// supplied reports whether t resolves to a non-nil value. A nil value, such
+// as JSON null or a nil pointer field, counts as not supplied.
+func (b *binder) supplied(t QueryToken) bool {
+	bd, ok := b.resolve(t)
+	return ok && indirectValue(reflect.ValueOf(bd.value)).IsValid()
+}
+
+// sliceValues returns the elements of a slice or array, treating []byte as
+// a scalar.
+func sliceValues(value any) (values []any, ok bool) {
+	rv := reflect.ValueOf(value)
+	switch {
+	case value == nil:
+		ok = true
+		goto end
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8:
+	case rv.Kind() == reflect.Array:
+	default:
+		goto end
+	}
+	values = make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	ok = true
+end:
+	return values, ok
+}
+
+// spreadValues flattens the slice bound to a spread placeholder.
+func (b *binder) spreadValues(bd binding) (values []any, ok bool) {
+	values, ok = sliceValues(bd.value)
+	if !ok {
+		b.errs = append(b.errs, NewErr(
+			ErrInvalidSpreadValue,
+			"name", bd.name,
+			"value_kind", fmt.Sprintf("%T", bd.value),
+		))
+		goto end
+	}
+	if len(values) > 0 {
+		goto end
+	}
+	ok = false
+	switch b.ps.emptySlice {
+	case NullOnEmptySlice:
+		values = []any{nil}
+		ok = true
+	default:
+		b.errs = append(b.errs, NewErr(ErrEmptySpreadValue, "name", bd.name))
+	}
+end:
+	return values, ok
+}
+
+// bindArg appends one driver argument and returns the placeholder for it.
+func (b *binder) bindArg(t QueryToken, name Selector, value any) (text string) {
+	b.next++
+	t.Index = b.next
+	t.Name = name
+	if b.style == NamedParamStyle {
+		native := name.NativeName()
+		b.checkNativeName(name, native)
+		value = sql.Named(string(native), value)
+	}
+	b.args = append(b.args, value)
+	b.sensitive = append(b.sensitive, t.Sensitive)
+	if b.render {
+		text = b.ps.format(t)
+	}
+	return text
+}
+
+// checkNativeName records an ErrNativeNameCollision when native is already
+// bound under another name. ParseSQL checks the names in the template, but
+// spread elements and row template fields are only named at bind time.
+func (b *binder) checkNativeName(name Selector, native Identifier) {
+	other, ok := b.natives[native]
+	if !ok {
+		b.natives[native] = name
+		return
+	}
+	if other == name {
+		return
+	}
+	b.errs = append(b.errs, NewErr(
+		ErrNativeNameCollision,
+		"name", name,
+		"other", other,
+		"native_name", native,
+	))
+}
+
+func (b *binder) write(t QueryToken, text string) {
+	if !b.render {
+		return
+	}
+	b.writeTo(t.Start)
+	b.sql.WriteString(text)
+	b.last = t.End
+}
+
+// writeTo copies the template text up to offset into the rendered SQL.
+func (b *binder) writeTo(offset int) {
+	if b.render {
+		b.sql.WriteString(string(b.ps.template[b.last:offset]))
+	}
+}
+
+func (b *binder) boundSQL() (bs BoundSQL, err error) {
+	err = CombineErrs(b.errs)
+	if err != nil {
+		goto end
+	}
+	if b.ps.maxParams > 0 && len(b.args) > b.ps.maxParams {
+		err = NewErr(
+			ErrTooManyParameters,
+			"count", len(b.args),
+			"max", b.ps.maxParams,
+		)
+		goto end
+	}
+	bs.SQL = b.ps.SQL
+	if b.render {
+		b.sql.WriteString(string(b.ps.template[b.last:]))
+		bs.SQL = SQLQuery(b.sql.String())
+	}
+	bs.Args = b.args
+	if slices.Contains(b.sensitive, true) {
+		bs.sensitive = b.sensitive
+	}
+end:
+	return bs, err
+}
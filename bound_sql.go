@@ -0,0 +1,87 @@
+package sqlparams
+
+import (
+	"errors"
+)
+
+// BoundSQL is a rewritten query paired with the driver arguments it must be
+// executed with, e.g. db.Query(string(bs.SQL), bs.Args...).
+type BoundSQL struct {
+	SQL  SQLQuery
+	Args []any
+
+	sensitive []bool // parallel to Args; nil when no argument is sensitive
+}
+
+// allRows renders every element of a row template.
+var allRows = rowWindow{from: 0, to: -1}
+
+// Bind looks up each parameter by name in values and returns the rewritten
+// SQL with its arguments ordered for the ParsedSQL's ParamStyle: one argument
+// per unique parameter index for indexed styles ($1, @p1), one argument per
+// occurrence for positional styles (?), or one sql.NamedArg per unique name
+// for named styles (@items_0_id).
+//
+// Names are looked up as exact keys first ("user.id"), then by walking
+// nested maps, structs and slices (values["user"]["id"]).
+//
+// Spread placeholders (:ids...) are expanded into one placeholder per slice
+// element, and row templates (:items(:id, :qty)) into one row per element,
+// with every later placeholder renumbered to match.
+//
+// Every missing parameter is reported, combined into a single error.
+func (ps ParsedSQL) Bind(values map[string]any) (bs BoundSQL, err error) {
+	return ps.bind(MapResolver(values), allRows).boundSQL()
+}
+
+// BindFrom binds like Bind, but looks up each parameter with resolver, e.g.
+// a NamespacedResolver routing :path.id and :body.name to their sources.
+func (ps ParsedSQL) BindFrom(resolver ParamResolver) (bs BoundSQL, err error) {
+	return ps.bind(resolver, allRows).boundSQL()
+}
+
+// BindBatches binds like Bind, but when the result would exceed MaxParams it
+// splits the elements of the query's row template across as many statements
+// as needed, each within the limit. Queries without exactly one row template
+// cannot be split and fail with ErrTooManyParameters.
+func (ps ParsedSQL) BindBatches(values map[string]any) (batches []BoundSQL, err error) {
+	var bs BoundSQL
+	var size, from, to int
+
+	b := ps.bind(MapResolver(values), allRows)
+	bs, err = b.boundSQL()
+	if err == nil {
+		batches = []BoundSQL{bs}
+		goto end
+	}
+	if !errors.Is(err, ErrTooManyParameters) || ps.rowTemplateCount() != 1 {
+		goto end
+	}
+
+	size = b.rowCount
+	for from < b.rowCount {
+		to = min(from+size, b.rowCount)
+		bs, err = ps.bind(MapResolver(values), rowWindow{from: from, to: to}).boundSQL()
+		if errors.Is(err, ErrTooManyParameters) && to-from > 1 {
+			size = (to - from) / 2
+			continue
+		}
+		if err != nil {
+			batches = nil
+			goto end
+		}
+		batches = append(batches, bs)
+		from = to
+	}
+end:
+	return batches, err
+}
+
+func (ps ParsedSQL) rowTemplateCount() (n int) {
+	for _, t := range ps.occurrences {
+		if t.Rows {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,143 @@
+package sqlparams
+
+import (
+	"maps"
+)
+
+// NativeTypes maps DBDataTypes to a database's own type names, used to cast
+// placeholders of typed parameters, e.g. uuid to "uniqueidentifier".
+type NativeTypes map[DBDataType]string
+
+// CastFunc wraps a rendered placeholder in a cast to nativeType, e.g.
+// "$1::uuid" or "CAST(@p1 AS uniqueidentifier)".
+type CastFunc func(placeholder, nativeType string) string
+
+// ColonCast renders PostgreSQL's placeholder::type casts.
+func ColonCast(placeholder, nativeType string) string {
+	return placeholder + "::" + nativeType
+}
+
+// StandardCast renders standard SQL CAST(placeholder AS type) casts.
+func StandardCast(placeholder, nativeType string) string {
+	return "CAST(" + placeholder + " AS " + nativeType + ")"
+}
+
+// With returns a copy of nt with overrides applied. An override mapped to
+// the empty string removes the cast for that type.
+func (nt NativeTypes) With(overrides NativeTypes) NativeTypes {
+	merged := maps.Clone(nt)
+	if merged == nil {
+		merged = make(NativeTypes, len(overrides))
+	}
+	for dt, native := range overrides {
+		if native == "" {
+			delete(merged, dt)
+			continue
+		}
+		merged[dt] = native
+	}
+	return merged
+}
+
+// Lookup returns the native type name for dt, ignoring NULL-ability of the
+// type and of array elements.
+func (nt NativeTypes) Lookup(dt DBDataType) (native string, ok bool) {
+	dt = trimNullable(dt.Normalize())
+	if elem, isArray := dt.Elem(); isArray {
+		dt = ArrayOf(trimNullable(elem), false)
+	}
+	native, ok = nt[dt]
+	return native, ok
+}
+
+// withArrays adds an entry for the array of each type in nt, named by
+// suffixing the element's native type with [].
+func withArrays(nt NativeTypes) NativeTypes {
+	for dt, native := range maps.Clone(nt) {
+		nt[ArrayOf(dt, false)] = native + arraySuffix
+	}
+	return nt
+}
+
+var (
+	// PostgresNativeTypes maps data types for PostgresDialect, including
+	// arrays of each, e.g. uuid[].
+	PostgresNativeTypes = withArrays(NativeTypes{
+		IntegerDBDataType:     "bigint",
+		RealDBDataType:        "double precision",
+		DecimalDBDataType:     "numeric",
+		StringDBDataType:      "text",
+		BooleanDBDataType:     "boolean",
+		UUIDDBDataType:        "uuid",
+		DateDBDataType:        "date",
+		TimeDBDataType:        "time",
+		TimestampDBDataType:   "timestamp",
+		TimestampTZDBDataType: "timestamptz",
+		BytesDBDataType:       "bytea",
+		JSONDBDataType:        "jsonb",
+	})
+
+	// MySQLNativeTypes maps data types for MySQLDialect, limited to the
+	// types MySQL's CAST accepts.
+	MySQLNativeTypes = NativeTypes{
+		IntegerDBDataType:   "SIGNED",
+		RealDBDataType:      "DOUBLE",
+		DecimalDBDataType:   "DECIMAL(65, 30)",
+		StringDBDataType:    "CHAR",
+		DateDBDataType:      "DATE",
+		TimeDBDataType:      "TIME(6)",
+		TimestampDBDataType: "DATETIME(6)",
+		BytesDBDataType:     "BINARY",
+		JSONDBDataType:      "JSON",
+	}
+
+	// SQLiteNativeTypes maps data types to SQLite's storage classes.
+	SQLiteNativeTypes = NativeTypes{
+		IntegerDBDataType: "INTEGER",
+		RealDBDataType:    "REAL",
+		DecimalDBDataType: "NUMERIC",
+		StringDBDataType:  "TEXT",
+		BooleanDBDataType: "INTEGER",
+		BytesDBDataType:   "BLOB",
+	}
+
+	// SQLServerNativeTypes maps data types for the SQL Server dialects.
+	SQLServerNativeTypes = NativeTypes{
+		IntegerDBDataType:     "bigint",
+		RealDBDataType:        "float",
+		DecimalDBDataType:     "decimal(38, 18)",
+		StringDBDataType:      "nvarchar(max)",
+		BooleanDBDataType:     "bit",
+		UUIDDBDataType:        "uniqueidentifier",
+		DateDBDataType:        "date",
+		TimeDBDataType:        "time",
+		TimestampDBDataType:   "datetime2",
+		TimestampTZDBDataType: "datetimeoffset",
+		BytesDBDataType:       "varbinary(max)",
+		JSONDBDataType:        "nvarchar(max)",
+	}
+
+	// OracleNativeTypes maps data types for the Oracle dialects.
+	OracleNativeTypes = NativeTypes{
+		IntegerDBDataType:     "NUMBER(19)",
+		RealDBDataType:        "BINARY_DOUBLE",
+		DecimalDBDataType:     "NUMBER",
+		StringDBDataType:      "VARCHAR2(4000)",
+		DateDBDataType:        "DATE",
+		TimestampDBDataType:   "TIMESTAMP",
+		TimestampTZDBDataType: "TIMESTAMP WITH TIME ZONE",
+	}
+)
+
+// castFormatter wraps format so placeholders of typed parameters with a
+// native type are rendered with cast.
+func castFormatter(format FormatTokenFunc, types NativeTypes, cast CastFunc) FormatTokenFunc {
+	return func(t QueryToken) (text string) {
+		text = format(t)
+		native, ok := types.Lookup(t.DataType)
+		if ok {
+			text = cast(text, native)
+		}
+		return text
+	}
+}
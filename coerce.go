@@ -0,0 +1,514 @@
+package sqlparams
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Coerce converts value to the Go type bound for dt, accepting the shapes
+// JSON decoding produces:
+//
+//	integer      int64; from integers, whole floats, json.Number and numeric strings
+//	real         float64; from numbers, json.Number and numeric strings
+//	decimal      string; from decimal strings, json.Number and numbers, never
+//	             rounded through float64 when given as text
+//	string       string; from strings, []byte, json.Number, numbers and booleans
+//	boolean      bool; from booleans, 0 and 1, and "true"/"false"/"t"/"f"/"1"/"0"
+//	uuid         string in canonical lowercase 8-4-4-4-12 form; from strings
+//	             with or without hyphens or braces, and [16]byte values
+//	date         time.Time at midnight UTC; from time.Time and "2006-01-02"
+//	time         string "15:04:05.999999999"; from time.Time and "15:04[:05]"
+//	timestamp    time.Time with its wall clock in UTC; from time.Time, RFC 3339
+//	             and "2006-01-02 15:04:05" strings
+//	timestamptz  time.Time in its own zone, from the same inputs; strings
+//	             without a zone are taken as UTC
+//	bytes        []byte; from []byte and base64 strings
+//	json         string of JSON text; strings and []byte must already hold valid
+//	             JSON, anything else (maps, slices, structs) is marshaled
+//	any          value unchanged
+//	T[]          a slice of T's Go type, []any when T is NULL-able; from any
+//	             slice or array, coercing each element to T
+//
+// Pointers are dereferenced. NULL (nil, or a nil pointer) is only accepted by
+// the NULL-able variants such as "integer?", and coerces to nil. Failures
+// wrap ErrInvalidDataType with the data type and the value's kind.
+func Coerce(value any, dt DBDataType) (out any, err error) {
+	var reason string
+	out, reason = coerceValue(value, dt.Normalize())
+	if reason != "" {
+		err = NewErr(
+			ErrInvalidDataType,
+			"data_type", dt,
+			"value_kind", fmt.Sprintf("%T", value),
+			"reason", reason,
+		)
+	}
+	return out, err
+}
+
+// coerceValue implements Coerce, returning the reason for a failure.
+func coerceValue(value any, dt DBDataType) (out any, reason string) {
+	var rv reflect.Value
+
+	if dt == "" || dt == AnyDBDataType {
+		out = value
+		goto end
+	}
+	rv = indirectValue(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		if !dt.Nullable() {
+			reason = "NULL is not allowed"
+		}
+		goto end
+	}
+
+	if elem, ok := dt.Elem(); ok {
+		out, reason = coerceArray(rv, elem)
+		goto end
+	}
+
+	switch trimNullable(dt) {
+	case IntegerDBDataType:
+		out, reason = coerceInteger(rv)
+	case RealDBDataType:
+		out, reason = coerceReal(rv)
+	case DecimalDBDataType:
+		out, reason = coerceDecimal(rv)
+	case StringDBDataType:
+		out, reason = coerceString(rv)
+	case BooleanDBDataType:
+		out, reason = coerceBoolean(rv)
+	case UUIDDBDataType:
+		out, reason = coerceUUID(rv)
+	case DateDBDataType:
+		out, reason = coerceDate(rv)
+	case TimeDBDataType:
+		out, reason = coerceTime(rv)
+	case TimestampDBDataType:
+		out, reason = coerceTimestamp(rv, false)
+	case TimestampTZDBDataType:
+		out, reason = coerceTimestamp(rv, true)
+	case BytesDBDataType:
+		out, reason = coerceBytes(rv)
+	case JSONDBDataType:
+		out, reason = coerceJSON(rv)
+	default:
+		reason = "unsupported data type"
+	}
+end:
+	return out, reason
+}
+
+func coerceInteger(rv reflect.Value) (out any, reason string) {
+	var f float64
+	var err error
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out = rv.Int()
+		goto end
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			reason = "out of range"
+			goto end
+		}
+		out = int64(rv.Uint())
+		goto end
+	case reflect.Float32, reflect.Float64:
+		// JSON numbers decode as float64
+		f = rv.Float()
+	case reflect.String:
+		// json.Number, or a number sent as a string
+		out, err = strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+		if err == nil {
+			goto end
+		}
+		out = nil
+		f, err = strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			reason = "not a number"
+			goto end
+		}
+	default:
+		reason = "not a number"
+		goto end
+	}
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		reason = "not a whole number"
+		goto end
+	}
+	out = int64(f)
+end:
+	return out, reason
+}
+
+func coerceReal(rv reflect.Value) (out any, reason string) {
+	var f float64
+	var err error
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		out = float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		out = rv.Float()
+	case reflect.String:
+		f, err = strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			reason = "not a number"
+			break
+		}
+		out = f
+	default:
+		reason = "not a number"
+	}
+	return out, reason
+}
+
+func coerceString(rv reflect.Value) (out any, reason string) {
+	switch rv.Kind() {
+	case reflect.String:
+		out = rv.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out = strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		out = strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		out = strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		out = strconv.FormatBool(rv.Bool())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			reason = "not a string"
+			break
+		}
+		out = string(rv.Bytes())
+	default:
+		reason = "not a string"
+	}
+	return out, reason
+}
+
+func coerceJSON(rv reflect.Value) (out any, reason string) {
+	var text []byte
+	var err error
+
+	switch {
+	case rv.Kind() == reflect.String:
+		text = []byte(rv.String())
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		// []byte and json.RawMessage
+		text = rv.Bytes()
+	default:
+		text, err = json.Marshal(rv.Interface())
+		if err != nil {
+			// err can quote the value, which may be sensitive
+			reason = "cannot be marshaled as JSON"
+			goto end
+		}
+	}
+	if !json.Valid(text) {
+		reason = "not valid JSON text"
+		goto end
+	}
+	out = string(text)
+end:
+	return out, reason
+}
+
+// decimalPattern matches decimal text, optionally in exponent notation.
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+func coerceDecimal(rv reflect.Value) (out any, reason string) {
+	var text string
+
+	switch rv.Kind() {
+	case reflect.String:
+		// json.Number keeps the digits as sent, so nothing is rounded
+		text = strings.TrimSpace(rv.String())
+		if !decimalPattern.MatchString(text) {
+			reason = "not a decimal number"
+			goto end
+		}
+		out = text
+	case reflect.Float32, reflect.Float64:
+		if math.IsInf(rv.Float(), 0) || math.IsNaN(rv.Float()) {
+			reason = "not a decimal number"
+			goto end
+		}
+		out = strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	default:
+		out, reason = coerceString(rv)
+		if reason != "" || rv.Kind() == reflect.Bool || rv.Kind() == reflect.Slice {
+			out, reason = nil, "not a decimal number"
+		}
+	}
+end:
+	return out, reason
+}
+
+func coerceBoolean(rv reflect.Value) (out any, reason string) {
+	var b bool
+	var err error
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		out = rv.Bool()
+		goto end
+	case reflect.String:
+		b, err = strconv.ParseBool(strings.TrimSpace(rv.String()))
+		if err != nil {
+			reason = "not a boolean"
+			goto end
+		}
+		out = b
+		goto end
+	}
+	// 0 and 1, e.g. from SQLite or JSON
+	out, reason = coerceInteger(rv)
+	switch {
+	case reason != "":
+	case out == int64(0):
+		out = false
+	case out == int64(1):
+		out = true
+	default:
+		reason = "not 0 or 1"
+	}
+	if reason != "" {
+		out, reason = nil, "not a boolean"
+	}
+end:
+	return out, reason
+}
+
+func coerceUUID(rv reflect.Value) (out any, reason string) {
+	var raw []byte
+	var hexText string
+
+	switch {
+	case rv.Kind() == reflect.String:
+		hexText = strings.TrimSpace(rv.String())
+		hexText = strings.TrimSuffix(strings.TrimPrefix(hexText, "{"), "}")
+		if len(hexText) == 36 && strings.Count(hexText, "-") == 4 &&
+			hexText[8] == '-' && hexText[13] == '-' && hexText[18] == '-' && hexText[23] == '-' {
+			hexText = strings.ReplaceAll(hexText, "-", "")
+		}
+		if len(hexText) != 32 {
+			reason = "not a UUID"
+			goto end
+		}
+		raw = make([]byte, 16)
+		if _, err := hex.Decode(raw, []byte(hexText)); err != nil {
+			reason = "not a UUID"
+			goto end
+		}
+	case (rv.Kind() == reflect.Array || rv.Kind() == reflect.Slice) &&
+		rv.Type().Elem().Kind() == reflect.Uint8 && rv.Len() == 16:
+		// e.g. [16]byte UUID types
+		raw = make([]byte, 16)
+		for i := range raw {
+			raw[i] = byte(rv.Index(i).Uint())
+		}
+	default:
+		reason = "not a UUID"
+		goto end
+	}
+	hexText = hex.EncodeToString(raw)
+	out = hexText[0:8] + "-" + hexText[8:12] + "-" + hexText[12:16] + "-" + hexText[16:20] + "-" + hexText[20:32]
+end:
+	return out, reason
+}
+
+// timeValue returns rv as a time.Time when it holds one.
+func timeValue(rv reflect.Value) (t time.Time, ok bool) {
+	if rv.CanInterface() {
+		t, ok = rv.Interface().(time.Time)
+	}
+	return t, ok
+}
+
+// dateLayout and timeLayouts are the accepted date and time-of-day strings.
+const dateLayout = time.DateOnly
+
+var timeLayouts = []string{"15:04:05.999999999", "15:04"}
+
+// timestampLayouts are the accepted timestamp strings, tried in order.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	dateLayout,
+}
+
+func coerceDate(rv reflect.Value) (out any, reason string) {
+	var err error
+
+	t, ok := timeValue(rv)
+	if ok {
+		out = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		goto end
+	}
+	if rv.Kind() != reflect.String {
+		reason = "not a date"
+		goto end
+	}
+	t, err = time.Parse(dateLayout, strings.TrimSpace(rv.String()))
+	if err != nil {
+		reason = "not a date"
+		goto end
+	}
+	out = t
+end:
+	return out, reason
+}
+
+func coerceTime(rv reflect.Value) (out any, reason string) {
+	var err error
+
+	t, ok := timeValue(rv)
+	if ok {
+		out = t.Format(timeLayouts[0])
+		goto end
+	}
+	if rv.Kind() != reflect.String {
+		reason = "not a time of day"
+		goto end
+	}
+	for _, layout := range timeLayouts {
+		t, err = time.Parse(layout, strings.TrimSpace(rv.String()))
+		if err == nil {
+			out = t.Format(timeLayouts[0])
+			goto end
+		}
+	}
+	reason = "not a time of day"
+end:
+	return out, reason
+}
+
+// coerceTimestamp converts to a time.Time, keeping its zone when withZone
+// and otherwise keeping its wall clock, in UTC.
+func coerceTimestamp(rv reflect.Value, withZone bool) (out any, reason string) {
+	var err error
+
+	t, ok := timeValue(rv)
+	if ok {
+		goto done
+	}
+	if rv.Kind() != reflect.String {
+		reason = "not a timestamp"
+		goto end
+	}
+	for _, layout := range timestampLayouts {
+		t, err = time.Parse(layout, strings.TrimSpace(rv.String()))
+		if err == nil {
+			goto done
+		}
+	}
+	reason = "not a timestamp"
+	goto end
+done:
+	if !withZone {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	out = t
+end:
+	return out, reason
+}
+
+// base64Encodings are tried in order when decoding bytes from a string.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+func coerceBytes(rv reflect.Value) (out any, reason string) {
+	var b []byte
+	var err error
+
+	switch {
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		out = rv.Bytes()
+		goto end
+	case rv.Kind() == reflect.String:
+		for _, enc := range base64Encodings {
+			b, err = enc.DecodeString(strings.TrimSpace(rv.String()))
+			if err == nil {
+				out = b
+				goto end
+			}
+		}
+	}
+	reason = "not bytes or base64 text"
+end:
+	return out, reason
+}
+
+// dataTypeGoTypes are the Go types Coerce produces, used to build typed
+// slices for arrays.
+var dataTypeGoTypes = map[DBDataType]reflect.Type{
+	IntegerDBDataType:     reflect.TypeFor[int64](),
+	RealDBDataType:        reflect.TypeFor[float64](),
+	DecimalDBDataType:     reflect.TypeFor[string](),
+	StringDBDataType:      reflect.TypeFor[string](),
+	BooleanDBDataType:     reflect.TypeFor[bool](),
+	UUIDDBDataType:        reflect.TypeFor[string](),
+	DateDBDataType:        reflect.TypeFor[time.Time](),
+	TimeDBDataType:        reflect.TypeFor[string](),
+	TimestampDBDataType:   reflect.TypeFor[time.Time](),
+	TimestampTZDBDataType: reflect.TypeFor[time.Time](),
+	BytesDBDataType:       reflect.TypeFor[[]byte](),
+	JSONDBDataType:        reflect.TypeFor[string](),
+}
+
+func coerceArray(rv reflect.Value, elem DBDataType) (out any, reason string) {
+	var slice reflect.Value
+	var v any
+	var n int
+
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		reason = "not an array"
+		goto end
+	}
+	n = rv.Len()
+	if goType, ok := dataTypeGoTypes[elem]; ok {
+		slice = reflect.MakeSlice(reflect.SliceOf(goType), n, n)
+	} else {
+		// NULL-able elements and any
+		slice = reflect.ValueOf(make([]any, n))
+	}
+	for i := 0; i < n; i++ {
+		v, reason = coerceValue(rv.Index(i).Interface(), elem)
+		if reason != "" {
+			reason = fmt.Sprintf("element %d: %s", i, reason)
+			goto end
+		}
+		if v != nil {
+			slice.Index(i).Set(reflect.ValueOf(v))
+		}
+	}
+	out = slice.Interface()
+end:
+	return out, reason
+}
+
+// trimNullable returns dt without its NULL-able '?' suffix.
+func trimNullable(dt DBDataType) DBDataType {
+	if dt.Nullable() {
+		return dt[:len(dt)-1]
+	}
+	return dt
+}
@@ -10,3 +10,7 @@ const (
 	// DefaultDBDataType specifies the default data type for database columns when none is specified.
 	DefaultDBDataType = StringDBDataType
 )
+
+// spreadSuffix marks a placeholder whose slice value expands at bind time,
+// e.g. IN (:ids...).
+const spreadSuffix = "..."
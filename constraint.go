@@ -0,0 +1,194 @@
+package sqlparams
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Constraint declares the values a parameter accepts, beyond its data type.
+// Zero-valued fields are not checked, and NULL values are never checked;
+// use a non-NULL-able data type to reject them.
+//
+// Checks other than MinItems and MaxItems apply to each element when the
+// value is a slice, e.g. for :ids... or :tags:string[].
+type Constraint struct {
+	// Min and Max bound numeric values, inclusive.
+	Min *float64
+	Max *float64
+
+	// MinLength and MaxLength bound the length of strings, in characters.
+	MinLength int
+	MaxLength int
+
+	// Pattern is a regular expression strings must match.
+	Pattern string
+
+	// Enum lists the allowed values. Numbers compare by value, so 1 matches
+	// int64(1) and float64(1).
+	Enum []any
+
+	// MinItems and MaxItems bound the length of slice values.
+	MinItems int
+	MaxItems int
+}
+
+// Limit returns a pointer to f, for Constraint.Min and Constraint.Max.
+func Limit(f float64) *float64 {
+	return &f
+}
+
+// constraint is a Constraint with its Pattern compiled.
+type constraint struct {
+	Constraint
+	pattern *regexp.Regexp
+}
+
+// violation is one failed check: the constraint's name and its limit.
+type violation struct {
+	constraint string
+	limit      any
+	value      any
+}
+
+// compileConstraints compiles the constraints in cs, reporting invalid
+// patterns and constraints on names the template does not use.
+func compileConstraints(cs map[Selector]Constraint, params Parameters) (compiled map[Selector]constraint, err error) {
+	var errs []error
+
+	if len(cs) == 0 {
+		goto end
+	}
+	compiled = make(map[Selector]constraint, len(cs))
+	for name, c := range cs {
+		if len(params.Indexes(name)) == 0 {
+			errs = append(errs, NewErr(
+				ErrInvalidConstraint,
+				"name", name,
+				"reason", "no such parameter",
+			))
+			continue
+		}
+		cc := constraint{Constraint: c}
+		if c.Pattern != "" {
+			cc.pattern, err = regexp.Compile(c.Pattern)
+			if err != nil {
+				errs = append(errs, NewErr(
+					ErrInvalidConstraint,
+					"name", name,
+					"pattern", c.Pattern,
+					err,
+				))
+				continue
+			}
+		}
+		compiled[name] = cc
+	}
+	err = CombineErrs(errs)
+	if err != nil {
+		compiled = nil
+	}
+end:
+	return compiled, err
+}
+
+// check returns every constraint value violates.
+func (c constraint) check(value any) (vs []violation) {
+	rv := indirectValue(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		goto end
+	}
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		if c.MinItems > 0 && rv.Len() < c.MinItems {
+			vs = append(vs, violation{constraint: "min_items", limit: c.MinItems, value: rv.Len()})
+		}
+		if c.MaxItems > 0 && rv.Len() > c.MaxItems {
+			vs = append(vs, violation{constraint: "max_items", limit: c.MaxItems, value: rv.Len()})
+		}
+		for i := 0; i < rv.Len(); i++ {
+			vs = append(vs, c.checkScalar(rv.Index(i).Interface())...)
+		}
+		goto end
+	}
+	vs = c.checkScalar(value)
+end:
+	return vs
+}
+
+func (c constraint) checkScalar(value any) (vs []violation) {
+	var f float64
+	var s string
+	var isNumber, isString bool
+
+	rv := indirectValue(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		goto end
+	}
+	f, isNumber = numberValue(rv)
+	if rv.Kind() == reflect.String {
+		s, isString = rv.String(), true
+	}
+
+	if c.Min != nil && (!isNumber || f < *c.Min) {
+		vs = append(vs, violation{constraint: "min", limit: *c.Min, value: value})
+	}
+	if c.Max != nil && (!isNumber || f > *c.Max) {
+		vs = append(vs, violation{constraint: "max", limit: *c.Max, value: value})
+	}
+	if c.MinLength > 0 && (!isString || utf8.RuneCountInString(s) < c.MinLength) {
+		vs = append(vs, violation{constraint: "min_length", limit: c.MinLength, value: value})
+	}
+	if c.MaxLength > 0 && (!isString || utf8.RuneCountInString(s) > c.MaxLength) {
+		vs = append(vs, violation{constraint: "max_length", limit: c.MaxLength, value: value})
+	}
+	if c.pattern != nil && (!isString || !c.pattern.MatchString(s)) {
+		vs = append(vs, violation{constraint: "pattern", limit: c.Pattern, value: value})
+	}
+	if len(c.Enum) > 0 && !c.inEnum(rv) {
+		vs = append(vs, violation{constraint: "enum", limit: c.Enum, value: value})
+	}
+end:
+	return vs
+}
+
+func (c constraint) inEnum(rv reflect.Value) bool {
+	f, isNumber := numberValue(rv)
+	for _, e := range c.Enum {
+		ev := indirectValue(reflect.ValueOf(e))
+		if !ev.IsValid() {
+			continue
+		}
+		if ef, ok := numberValue(ev); ok && isNumber {
+			if ef == f {
+				return true
+			}
+			continue
+		}
+		if ev.Kind() == reflect.String && rv.Kind() == reflect.String {
+			if ev.String() == rv.String() {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(ev.Interface(), rv.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// numberValue returns rv as a float64 when it holds a number, including
+// json.Number and numeric strings such as coerced decimals.
+func numberValue(rv reflect.Value) (f float64, ok bool) {
+	var out any
+	var reason string
+
+	out, reason = coerceReal(rv)
+	if reason != "" {
+		goto end
+	}
+	f, ok = out.(float64), !math.IsNaN(out.(float64))
+end:
+	return f, ok
+}
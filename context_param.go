@@ -0,0 +1,126 @@
+package sqlparams
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextParam is a value carried in a context.Context that templates read
+// as :ctx.<name>, e.g. a ContextKey registered with RegisterContextKey.
+type ContextParam interface {
+	ParamName() Selector
+	ParamValue(ctx context.Context) (value any, ok bool)
+}
+
+// ContextKey is a typed context key for values such as a tenant or user ID,
+// bound to :ctx.<name> once registered:
+//
+//	var TenantID = sqlparams.NewContextKey[int64]("tenant_id")
+//
+//	func init() {
+//		err := sqlparams.RegisterContextKey(TenantID)
+//		...
+//	}
+//
+//	ctx = TenantID.WithValue(ctx, 42)
+type ContextKey[T any] struct {
+	name Selector
+}
+
+// NewContextKey returns the key for :ctx.name. Keys are distinct context
+// keys even when they share a name, as with context.WithValue.
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: Selector(name)}
+}
+
+// WithValue returns a copy of ctx carrying value for k.
+func (k *ContextKey[T]) WithValue(ctx context.Context, value T) context.Context {
+	return context.WithValue(ctx, k, value)
+}
+
+// Value returns the value ctx carries for k.
+func (k *ContextKey[T]) Value(ctx context.Context) (value T, ok bool) {
+	value, ok = ctx.Value(k).(T)
+	return value, ok
+}
+
+// ParamName returns the name templates use after ctx., e.g. tenant_id.
+func (k *ContextKey[T]) ParamName() Selector {
+	return k.name
+}
+
+// ParamValue returns the value ctx carries for k.
+func (k *ContextKey[T]) ParamValue(ctx context.Context) (value any, ok bool) {
+	return k.Value(ctx)
+}
+
+// contextParams holds the registered ContextParams by name.
+var contextParams = struct {
+	sync.RWMutex
+	params map[Selector]ContextParam
+}{
+	params: make(map[Selector]ContextParam),
+}
+
+// RegisterContextKey makes p available to templates as :ctx.<name>. Names
+// follow the rules for identifiers and cannot be registered twice.
+func RegisterContextKey(p ContextParam) (err error) {
+	var i int
+	var name string
+
+	if p == nil {
+		err = NewErr(ErrInvalidContextKey, "reason", "nil key")
+		goto end
+	}
+	name = string(p.ParamName())
+	contextParams.Lock()
+	defer contextParams.Unlock()
+	if !readIdent(name, &i) || i != len(name) {
+		err = NewErr(ErrInvalidContextKey, "name", name, "reason", "invalid name")
+		goto end
+	}
+	if _, ok := contextParams.params[Selector(name)]; ok {
+		err = NewErr(ErrInvalidContextKey, "name", name, "reason", "already registered")
+		goto end
+	}
+	contextParams.params[Selector(name)] = p
+end:
+	return err
+}
+
+// ContextResolver resolves the names of registered context keys against
+// ctx, for the ctx namespace of a NamespacedResolver. Names that were never
+// registered fail with ErrUnknownContextKey.
+func ContextResolver(ctx context.Context) ParamResolver {
+	return ResolverFunc(func(name Selector) (value any, ok bool, err error) {
+		contextParams.RLock()
+		p, found := contextParams.params[name]
+		contextParams.RUnlock()
+		if !found {
+			err = NewErr(ErrUnknownContextKey, "name", name)
+			goto end
+		}
+		value, ok = p.ParamValue(ctx)
+	end:
+		return value, ok, err
+	})
+}
+
+// BindContext binds like Bind, but resolves :ctx.* parameters from ctx via
+// registered context keys. They are never read from values, so callers
+// cannot supply a tenant or user ID that the context does not carry.
+func (ps ParsedSQL) BindContext(ctx context.Context, values map[string]any) (bs BoundSQL, err error) {
+	return ps.BindFrom(withContext(ctx, MapResolver(values)))
+}
+
+// withContext resolves :ctx.* names from ctx and all others with resolver.
+func withContext(ctx context.Context, resolver ParamResolver) ParamResolver {
+	ctxResolver := ContextResolver(ctx)
+	return ResolverFunc(func(name Selector) (value any, ok bool, err error) {
+		ns, rest := splitNamespace(name)
+		if ns == ContextNamespace {
+			return ctxResolver.Resolve(rest)
+		}
+		return resolver.Resolve(name)
+	})
+}
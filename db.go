@@ -0,0 +1,291 @@
+package sqlparams
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// queryer is what *sql.DB, *sql.Tx and *sql.Conn have in common.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// namedQueryer runs named-parameter templates against a queryer. It
+// provides the QueryNamed, QueryRowNamed and ExecNamed methods of DB, Tx and
+// Conn.
+type namedQueryer struct {
+	q       queryer
+	dialect Dialect
+	args    ParseSQLArgs
+	cache   *ParseCache // nil parses every call
+	hook    QueryHook   // nil reports nothing
+}
+
+// QueryNamed parses query with the dialect, binds it with params and runs it
+// with QueryContext. params may be a map[string]any, a struct or pointer to
+// one, any other map with string keys, a ParamResolver, or nil. Except with a
+// ParamResolver, :ctx.* parameters are read from ctx as by BindContext.
+func (nq namedQueryer) QueryNamed(ctx context.Context, query SQLQuery, params any) (rows *sql.Rows, err error) {
+	var bs BoundSQL
+
+	start := time.Now()
+	bs, err = nq.bind(ctx, query, params)
+	if err != nil {
+		goto end
+	}
+	rows, err = nq.q.QueryContext(ctx, string(bs.SQL), bs.Args...)
+end:
+	nq.report(ctx, query, bs, start, err)
+	return rows, err
+}
+
+// QueryRowNamed is QueryNamed for queries expected to return at most one
+// row. Parse and bind errors are returned by the Row's Scan.
+func (nq namedQueryer) QueryRowNamed(ctx context.Context, query SQLQuery, params any) (row *Row) {
+	start := time.Now()
+	bs, err := nq.bind(ctx, query, params)
+	if err != nil {
+		nq.report(ctx, query, bs, start, err)
+		return &Row{err: err}
+	}
+	row = &Row{row: nq.q.QueryRowContext(ctx, string(bs.SQL), bs.Args...)}
+	nq.report(ctx, query, bs, start, row.row.Err())
+	return row
+}
+
+// ExecNamed is QueryNamed for statements that return no rows, run with
+// ExecContext.
+func (nq namedQueryer) ExecNamed(ctx context.Context, query SQLQuery, params any) (result sql.Result, err error) {
+	var bs BoundSQL
+
+	start := time.Now()
+	bs, err = nq.bind(ctx, query, params)
+	if err != nil {
+		goto end
+	}
+	result, err = nq.q.ExecContext(ctx, string(bs.SQL), bs.Args...)
+end:
+	nq.report(ctx, query, bs, start, err)
+	return result, err
+}
+
+func (nq namedQueryer) bind(ctx context.Context, query SQLQuery, params any) (bs BoundSQL, err error) {
+	var ps ParsedSQL
+
+	if nq.cache != nil {
+		ps, err = nq.cache.ParseSQL(nq.dialect, query, nq.args)
+	} else {
+		ps, err = nq.dialect.ParseSQL(query, nq.args)
+	}
+	if err != nil {
+		goto end
+	}
+	bs, err = ps.BindFrom(paramsResolver(ctx, params))
+end:
+	return bs, err
+}
+
+// paramsResolver returns the resolver for the params of a named call.
+func paramsResolver(ctx context.Context, params any) (resolver ParamResolver) {
+	switch p := params.(type) {
+	case ParamResolver:
+		resolver = p
+	case map[string]any:
+		resolver = withContext(ctx, MapResolver(p))
+	default:
+		resolver = withContext(ctx, ResolverFunc(func(name Selector) (value any, ok bool, err error) {
+			value, ok = lookupSelector(params, name)
+			return value, ok, err
+		}))
+	}
+	return resolver
+}
+
+// Row is the result of QueryRowNamed: a *sql.Row, or the error that kept
+// the query from running.
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+// Scan copies the row's columns into dest, as sql.Row.Scan does, or returns
+// the error that kept the query from running.
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// Err returns the error, if any, from parsing, binding or running the query,
+// as sql.Row.Err does.
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Err()
+}
+
+// DB wraps a *sql.DB to run templates with :name placeholders, rewritten
+// for its Dialect:
+//
+//	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+//	rows, err := db.QueryNamed(ctx, "SELECT * FROM users WHERE id = :id", map[string]any{"id": 7})
+type DB struct {
+	namedQueryer
+	db    *sql.DB
+	stmts *StmtCache // nil runs calls unprepared
+}
+
+// NewDB returns a DB that parses templates with dialect and, when given,
+// args. Parsed templates are kept in a ParseCache of DefaultParseCacheSize,
+// shared with the DB's transactions and connections.
+func NewDB(db *sql.DB, dialect Dialect, args ...ParseSQLArgs) *DB {
+	var opts ParseSQLArgs
+	if len(args) > 0 {
+		opts = args[0]
+	}
+	return &DB{
+		namedQueryer: namedQueryer{
+			q:       db,
+			dialect: dialect,
+			args:    opts,
+			cache:   NewParseCache(DefaultParseCacheSize),
+		},
+		db: db,
+	}
+}
+
+// DB returns the wrapped *sql.DB.
+func (db *DB) DB() *sql.DB {
+	return db.db
+}
+
+// ParseCache returns the cache of parsed templates, e.g. for its Stats, or
+// nil when caching is disabled.
+func (db *DB) ParseCache() *ParseCache {
+	return db.cache
+}
+
+// WithParseCache returns a copy of db that caches parsed templates in
+// cache, or parses every call when cache is nil. A cache may be shared by
+// DBs with different dialects and ParseSQLArgs.
+func (db *DB) WithParseCache(cache *ParseCache) *DB {
+	clone := *db
+	clone.cache = cache
+	return &clone
+}
+
+// StmtCache returns the cache of prepared statements, or nil when calls
+// run unprepared.
+func (db *DB) StmtCache() *StmtCache {
+	return db.stmts
+}
+
+// WithStmtCache returns a copy of db whose named calls run cached prepared
+// statements, e.g. WithStmtCache(NewStmtCache(db.DB(), 256)), or run
+// unprepared when cache is nil. Transactions and connections always run
+// unprepared.
+func (db *DB) WithStmtCache(cache *StmtCache) *DB {
+	clone := *db
+	clone.stmts = cache
+	clone.q = db.db
+	if cache != nil {
+		clone.q = cache
+	}
+	return &clone
+}
+
+// Close closes the cached prepared statements, if any. It does not close
+// the wrapped *sql.DB, which belongs to the caller: close it after db.
+func (db *DB) Close() (err error) {
+	if db.stmts != nil {
+		err = db.stmts.Close()
+	}
+	return err
+}
+
+// BeginTx starts a transaction whose named calls use db's dialect.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *Tx, err error) {
+	var sqlTx *sql.Tx
+
+	sqlTx, err = db.db.BeginTx(ctx, opts)
+	if err != nil {
+		goto end
+	}
+	tx = &Tx{namedQueryer: db.with(sqlTx), tx: sqlTx}
+end:
+	return tx, err
+}
+
+// Conn returns a single connection whose named calls use db's dialect.
+func (db *DB) Conn(ctx context.Context) (conn *Conn, err error) {
+	var sqlConn *sql.Conn
+
+	sqlConn, err = db.db.Conn(ctx)
+	if err != nil {
+		goto end
+	}
+	conn = &Conn{namedQueryer: db.with(sqlConn), conn: sqlConn}
+end:
+	return conn, err
+}
+
+// with returns nq's settings applied to q.
+func (nq namedQueryer) with(q queryer) namedQueryer {
+	nq.q = q
+	return nq
+}
+
+// Tx wraps a *sql.Tx to run templates with :name placeholders.
+type Tx struct {
+	namedQueryer
+	tx *sql.Tx
+}
+
+// Tx returns the wrapped *sql.Tx.
+func (tx *Tx) Tx() *sql.Tx {
+	return tx.tx
+}
+
+// Commit commits the transaction.
+func (tx *Tx) Commit() error {
+	return tx.tx.Commit()
+}
+
+// Rollback aborts the transaction.
+func (tx *Tx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// Conn wraps a *sql.Conn to run templates with :name placeholders.
+type Conn struct {
+	namedQueryer
+	conn *sql.Conn
+}
+
+// Conn returns the wrapped *sql.Conn.
+func (conn *Conn) Conn() *sql.Conn {
+	return conn.conn
+}
+
+// BeginTx starts a transaction on the connection.
+func (conn *Conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *Tx, err error) {
+	var sqlTx *sql.Tx
+
+	sqlTx, err = conn.conn.BeginTx(ctx, opts)
+	if err != nil {
+		goto end
+	}
+	tx = &Tx{namedQueryer: conn.with(sqlTx), tx: sqlTx}
+end:
+	return tx, err
+}
+
+// Close returns the connection to the pool.
+func (conn *Conn) Close() error {
+	return conn.conn.Close()
+}
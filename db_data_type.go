@@ -18,15 +18,79 @@ type DBDataTypes []DBDataType
 type DBDataType string
 
 func (dt DBDataType) Normalize() DBDataType {
+	if elem, ok := dt.Elem(); ok {
+		return ArrayOf(elem.Normalize(), dt.Nullable())
+	}
 	switch dt {
 	case IntDBDataType:
 		return IntegerDBDataType
 	case IntDBDataTypeOrNULL:
 		return IntegerDBDataTypeOrNULL
+	case BoolDBDataType:
+		return BooleanDBDataType
+	case BoolDBDataTypeOrNULL:
+		return BooleanDBDataTypeOrNULL
+	}
+	return dt
+}
+
+// Nullable reports whether dt is a NULL-able variant such as "integer?".
+func (dt DBDataType) Nullable() bool {
+	return strings.HasSuffix(string(dt), "?")
+}
+
+// Elem returns the element type of an array type, e.g. "integer" for
+// "integer[]" and "string?" for "string?[]?".
+func (dt DBDataType) Elem() (elem DBDataType, ok bool) {
+	s := strings.TrimSuffix(string(dt), "?")
+	if !strings.HasSuffix(s, arraySuffix) {
+		goto end
+	}
+	elem, ok = DBDataType(strings.TrimSuffix(s, arraySuffix)), true
+end:
+	return elem, ok
+}
+
+// ArrayOf returns the array type of elem, NULL-able when nullable.
+func ArrayOf(elem DBDataType, nullable bool) (dt DBDataType) {
+	dt = elem + arraySuffix
+	if nullable {
+		dt += "?"
 	}
 	return dt
 }
 
+// arraySuffix marks an array data type, e.g. integer[].
+const arraySuffix = "[]"
+
+// scalarDBDataTypes are the valid non-array data types, without aliases.
+var scalarDBDataTypes = map[DBDataType]bool{
+	AnyDBDataType:         true,
+	IntegerDBDataType:     true,
+	RealDBDataType:        true,
+	StringDBDataType:      true,
+	JSONDBDataType:        true,
+	BooleanDBDataType:     true,
+	UUIDDBDataType:        true,
+	DateDBDataType:        true,
+	TimeDBDataType:        true,
+	TimestampDBDataType:   true,
+	TimestampTZDBDataType: true,
+	DecimalDBDataType:     true,
+	BytesDBDataType:       true,
+}
+
+// valid reports whether a normalized dt is a known data type or an array of
+// one. Arrays of arrays are not supported.
+func (dt DBDataType) valid() bool {
+	elem, ok := dt.Elem()
+	if ok {
+		_, nested := elem.Elem()
+		return !nested && elem.valid()
+	}
+	return scalarDBDataTypes[trimNullable(dt)]
+}
+
 const (
 	AnyDBDataType           DBDataType = "any"
 	IntegerDBDataType       DBDataType = "integer"
@@ -39,6 +103,26 @@ const (
 	RealDBDataTypeOrNULL    DBDataType = "real?"
 	StringDBDataTypeOrNULL  DBDataType = "string?"
 	JSONDBDataTypeOrNULL    DBDataType = "json?"
+
+	BooleanDBDataType     DBDataType = "boolean"
+	BoolDBDataType        DBDataType = "bool"
+	UUIDDBDataType        DBDataType = "uuid"
+	DateDBDataType        DBDataType = "date"
+	TimeDBDataType        DBDataType = "time"
+	TimestampDBDataType   DBDataType = "timestamp"
+	TimestampTZDBDataType DBDataType = "timestamptz"
+	DecimalDBDataType     DBDataType = "decimal"
+	BytesDBDataType       DBDataType = "bytes"
+
+	BooleanDBDataTypeOrNULL     DBDataType = "boolean?"
+	BoolDBDataTypeOrNULL        DBDataType = "bool?"
+	UUIDDBDataTypeOrNULL        DBDataType = "uuid?"
+	DateDBDataTypeOrNULL        DBDataType = "date?"
+	TimeDBDataTypeOrNULL        DBDataType = "time?"
+	TimestampDBDataTypeOrNULL   DBDataType = "timestamp?"
+	TimestampTZDBDataTypeOrNULL DBDataType = "timestamptz?"
+	DecimalDBDataTypeOrNULL     DBDataType = "decimal?"
+	BytesDBDataTypeOrNULL       DBDataType = "bytes?"
 )
 
 type DBRowType string
@@ -107,16 +191,15 @@ end:
 	return cts, err
 }
 
+// ParseDBDataType parses a data type name such as "integer", "timestamptz?"
+// or "uuid[]", normalizing aliases ("int", "bool") and case.
 func ParseDBDataType(s string) (dt DBDataType, err error) {
 	if s == "" {
 		dt = DefaultDBDataType
 		goto end
 	}
 	dt = DBDataType(strings.ToLower(s)).Normalize()
-	switch dt {
-	case AnyDBDataType, IntegerDBDataType, RealDBDataType, StringDBDataType, JSONDBDataType, IntegerDBDataTypeOrNULL, RealDBDataTypeOrNULL, StringDBDataTypeOrNULL, JSONDBDataTypeOrNULL:
-		// Nothing to do
-	default:
+	if !dt.valid() {
 		err = NewErr(ErrInvalidDataType, "data_type", s)
 		dt = ""
 	}
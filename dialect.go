@@ -0,0 +1,172 @@
+package sqlparams
+
+import (
+	"fmt"
+)
+
+// Dialect bundles the placeholder conventions of a database driver so a
+// template can be parsed without hand-writing a formatter.
+type Dialect struct {
+	// Name identifies the dialect, e.g. "postgres".
+	Name string
+
+	// FormatTokenFunc renders the native placeholder for each occurrence.
+	FormatTokenFunc FormatTokenFunc
+
+	// ParamStyle declares how the placeholders are bound.
+	ParamStyle ParamStyle
+
+	// MaxParams is the driver's bind-parameter limit per statement.
+	MaxParams int
+
+	// NativeTypes maps parameter data types (:id:uuid) to the database's
+	// type names, and CastFunc renders a placeholder cast to one.
+	NativeTypes NativeTypes
+	CastFunc    CastFunc
+
+	// CastParams renders placeholders of typed parameters with CastFunc,
+	// e.g. $1::uuid. Parameters without a native type are not cast. It is
+	// off in the presets; see WithCasts.
+	CastParams bool
+}
+
+var (
+	// PostgresDialect renders $1, $2, ...
+	PostgresDialect = Dialect{
+		Name:            "postgres",
+		FormatTokenFunc: IndexFormatter("$%d"),
+		ParamStyle:      IndexedParamStyle,
+		MaxParams:       65535,
+		NativeTypes:     PostgresNativeTypes,
+		CastFunc:        ColonCast,
+	}
+
+	// MySQLDialect renders ? for every occurrence.
+	MySQLDialect = Dialect{
+		Name:            "mysql",
+		FormatTokenFunc: PositionalFormatter("?"),
+		ParamStyle:      PositionalParamStyle,
+		MaxParams:       65535,
+		NativeTypes:     MySQLNativeTypes,
+		CastFunc:        StandardCast,
+	}
+
+	// SQLiteDialect renders ? for every occurrence.
+	SQLiteDialect = Dialect{
+		Name:            "sqlite",
+		FormatTokenFunc: PositionalFormatter("?"),
+		ParamStyle:      PositionalParamStyle,
+		MaxParams:       32766,
+		NativeTypes:     SQLiteNativeTypes,
+		CastFunc:        StandardCast,
+	}
+
+	// SQLServerDialect renders @p1, @p2, ...
+	SQLServerDialect = Dialect{
+		Name:            "sqlserver",
+		FormatTokenFunc: IndexFormatter("@p%d"),
+		ParamStyle:      IndexedParamStyle,
+		MaxParams:       2100,
+		NativeTypes:     SQLServerNativeTypes,
+		CastFunc:        StandardCast,
+	}
+
+	// OracleDialect renders :1, :2, ...
+	OracleDialect = Dialect{
+		Name:            "oracle",
+		FormatTokenFunc: IndexFormatter(":%d"),
+		ParamStyle:      IndexedParamStyle,
+		MaxParams:       65535,
+		NativeTypes:     OracleNativeTypes,
+		CastFunc:        StandardCast,
+	}
+
+	// SQLServerNamedDialect renders @items_0_id and binds sql.NamedArg values.
+	SQLServerNamedDialect = Dialect{
+		Name:            "sqlserver-named",
+		FormatTokenFunc: NamedFormatter("@"),
+		ParamStyle:      NamedParamStyle,
+		MaxParams:       2100,
+		NativeTypes:     SQLServerNativeTypes,
+		CastFunc:        StandardCast,
+	}
+
+	// OracleNamedDialect renders :items_0_id and binds sql.NamedArg values.
+	OracleNamedDialect = Dialect{
+		Name:            "oracle-named",
+		FormatTokenFunc: NamedFormatter(":"),
+		ParamStyle:      NamedParamStyle,
+		MaxParams:       65535,
+		NativeTypes:     OracleNativeTypes,
+		CastFunc:        StandardCast,
+	}
+
+	// SQLiteNamedDialect renders $items_0_id and binds sql.NamedArg values.
+	SQLiteNamedDialect = Dialect{
+		Name:            "sqlite-named",
+		FormatTokenFunc: NamedFormatter("$"),
+		ParamStyle:      NamedParamStyle,
+		MaxParams:       32766,
+		NativeTypes:     SQLiteNativeTypes,
+		CastFunc:        StandardCast,
+	}
+)
+
+// ParseSQL parses sqlText using the dialect's formatter and ParamStyle. The
+// dialect's settings take precedence over those in args, except that a
+// non-zero args MaxParams overrides the dialect's.
+func (d Dialect) ParseSQL(sqlText SQLQuery, args ...ParseSQLArgs) (ParsedSQL, error) {
+	var opts ParseSQLArgs
+	if len(args) > 0 {
+		opts = args[0]
+	}
+	opts.ParamStyle = d.ParamStyle
+	if opts.MaxParams == 0 {
+		opts.MaxParams = d.MaxParams
+	}
+	format := d.FormatTokenFunc
+	if d.CastParams && d.CastFunc != nil && format != nil {
+		format = castFormatter(format, d.NativeTypes, d.CastFunc)
+	}
+	return parseSQL(sqlText, format, opts)
+}
+
+// WithCasts returns a copy of d that casts placeholders of typed parameters
+// to their native types, using types in place of d.NativeTypes when given.
+// Start from the dialect's map to override only some types:
+//
+//	d := PostgresDialect.WithCasts(PostgresNativeTypes.With(NativeTypes{
+//		JSONDBDataType: "json",
+//	}))
+func (d Dialect) WithCasts(types ...NativeTypes) Dialect {
+	d.CastParams = true
+	if len(types) > 0 {
+		d.NativeTypes = types[0]
+	}
+	return d
+}
+
+// IndexFormatter returns a FormatTokenFunc that renders the parameter index
+// with a fmt verb, e.g. IndexFormatter("$%d").
+func IndexFormatter(format string) FormatTokenFunc {
+	return func(t QueryToken) string {
+		return fmt.Sprintf(format, t.Index)
+	}
+}
+
+// PositionalFormatter returns a FormatTokenFunc that renders the same
+// placeholder for every occurrence, e.g. PositionalFormatter("?").
+func PositionalFormatter(placeholder string) FormatTokenFunc {
+	return func(QueryToken) string {
+		return placeholder
+	}
+}
+
+// NamedFormatter returns a FormatTokenFunc that renders prefix followed by
+// the Selector's NativeName, e.g. NamedFormatter("@") renders :items[0].id
+// as @items_0_id.
+func NamedFormatter(prefix string) FormatTokenFunc {
+	return func(t QueryToken) string {
+		return prefix + string(t.Name.NativeName())
+	}
+}
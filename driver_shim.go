@@ -0,0 +1,484 @@
+package sqlparams
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"slices"
+)
+
+// WrapDriver returns a driver that rewrites :name placeholders in the SQL
+// passed to database/sql for d, using dialect, and maps sql.NamedArg values
+// into the positions d expects:
+//
+//	db.QueryContext(ctx, "SELECT * FROM users WHERE id = :id", sql.Named("id", 7))
+//
+// Queries run without named arguments are passed to d unchanged, so native
+// placeholders keep working. Named arguments cannot be mixed with positional
+// ones, see ErrUnnamedArgument, and must each be used by the query, see
+// ErrUnusedArgument.
+func WrapDriver(d driver.Driver, dialect Dialect) driver.Driver {
+	return &shimDriver{driver: d, dialect: dialect, cache: NewParseCache(DefaultParseCacheSize)}
+}
+
+// WrapConnector returns a connector whose connections rewrite :name
+// placeholders as WrapDriver's do, for use with sql.OpenDB.
+func WrapConnector(c driver.Connector, dialect Dialect) driver.Connector {
+	d := &shimDriver{driver: c.Driver(), dialect: dialect, cache: NewParseCache(DefaultParseCacheSize)}
+	return &shimConnector{connector: c, driver: d}
+}
+
+// RegisterDriver registers WrapDriver(d, dialect) with database/sql as name,
+// for sql.Open. A name already registered fails with ErrDuplicateDriver,
+// where sql.Register would panic.
+func RegisterDriver(name string, d driver.Driver, dialect Dialect) (err error) {
+	if slices.Contains(sql.Drivers(), name) {
+		err = NewErr(ErrDuplicateDriver, "driver", name)
+		goto end
+	}
+	sql.Register(name, WrapDriver(d, dialect))
+end:
+	return err
+}
+
+type shimDriver struct {
+	driver  driver.Driver
+	dialect Dialect
+	cache   *ParseCache
+}
+
+func (d *shimDriver) Open(name string) (conn driver.Conn, err error) {
+	conn, err = d.driver.Open(name)
+	if err != nil {
+		goto end
+	}
+	conn = &shimConn{conn: conn, driver: d}
+end:
+	return conn, err
+}
+
+// OpenConnector wraps the connector of drivers that provide one.
+func (d *shimDriver) OpenConnector(name string) (c driver.Connector, err error) {
+	dc, ok := d.driver.(driver.DriverContext)
+	if !ok {
+		c = &shimConnector{connector: dsnConnector{dsn: name, driver: d.driver}, driver: d}
+		goto end
+	}
+	c, err = dc.OpenConnector(name)
+	if err != nil {
+		goto end
+	}
+	c = &shimConnector{connector: c, driver: d}
+end:
+	return c, err
+}
+
+type shimConnector struct {
+	connector driver.Connector
+	driver    *shimDriver
+}
+
+func (c *shimConnector) Connect(ctx context.Context) (conn driver.Conn, err error) {
+	conn, err = c.connector.Connect(ctx)
+	if err != nil {
+		goto end
+	}
+	conn = &shimConn{conn: conn, driver: c.driver}
+end:
+	return conn, err
+}
+
+func (c *shimConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dsnConnector is the connector database/sql uses for drivers without one.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// shimConn rewrites queries run with named arguments before passing them to
+// the wrapped connection.
+type shimConn struct {
+	conn   driver.Conn
+	driver *shimDriver
+}
+
+var (
+	_ driver.ConnPrepareContext = (*shimConn)(nil)
+	_ driver.ConnBeginTx        = (*shimConn)(nil)
+	_ driver.QueryerContext     = (*shimConn)(nil)
+	_ driver.ExecerContext      = (*shimConn)(nil)
+	_ driver.NamedValueChecker  = (*shimConn)(nil)
+	_ driver.Pinger             = (*shimConn)(nil)
+	_ driver.SessionResetter    = (*shimConn)(nil)
+	_ driver.Validator          = (*shimConn)(nil)
+)
+
+func (c *shimConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext parses query and prepares its rewritten SQL. Templates
+// that are re-rendered at bind time, such as those with spreads, are not
+// prepared; their statements run each call unprepared.
+func (c *shimConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	var ps ParsedSQL
+	var prepared driver.Stmt
+
+	ps, err = c.driver.cache.ParseSQL(c.driver.dialect, SQLQuery(query))
+	if err != nil {
+		goto end
+	}
+	if len(ps.Parameters()) == 0 {
+		// Nothing to rewrite; native placeholders pass through
+		stmt, err = c.prepare(ctx, query)
+		goto end
+	}
+	if !ps.dynamic {
+		prepared, err = c.prepare(ctx, string(ps.SQL))
+		if err != nil {
+			goto end
+		}
+	}
+	stmt = &shimStmt{conn: c, ps: ps, stmt: prepared}
+end:
+	return stmt, err
+}
+
+func (c *shimConn) prepare(ctx context.Context, query string) (driver.Stmt, error) {
+	if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return pc.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *shimConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *shimConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *shimConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.conn.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+// CheckNamedValue leaves named values as they are until the template is
+// bound, so slices can fill spreads and row templates, and otherwise defers
+// to the wrapped connection.
+func (c *shimConn) CheckNamedValue(nv *driver.NamedValue) (err error) {
+	if nv.Name != "" {
+		goto end
+	}
+	err = c.convert(nv)
+end:
+	return err
+}
+
+// convert converts nv as the wrapped connection, or failing that
+// database/sql, would.
+func (c *shimConn) convert(nv *driver.NamedValue) (err error) {
+	if nvc, ok := c.conn.(driver.NamedValueChecker); ok {
+		err = nvc.CheckNamedValue(nv)
+		if err != driver.ErrSkip {
+			goto end
+		}
+	}
+	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+end:
+	return err
+}
+
+func (c *shimConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	var ps ParsedSQL
+
+	q, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		// database/sql falls back to PrepareContext
+		err = driver.ErrSkip
+		goto end
+	}
+	if !hasNamedArg(args) {
+		rows, err = q.QueryContext(ctx, query, args)
+		goto end
+	}
+	ps, err = c.driver.cache.ParseSQL(c.driver.dialect, SQLQuery(query))
+	if err != nil {
+		goto end
+	}
+	query, args, err = c.bind(ps, args)
+	if err != nil {
+		goto end
+	}
+	rows, err = q.QueryContext(ctx, query, args)
+end:
+	return rows, err
+}
+
+func (c *shimConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (result driver.Result, err error) {
+	var ps ParsedSQL
+
+	e, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		err = driver.ErrSkip
+		goto end
+	}
+	if !hasNamedArg(args) {
+		result, err = e.ExecContext(ctx, query, args)
+		goto end
+	}
+	ps, err = c.driver.cache.ParseSQL(c.driver.dialect, SQLQuery(query))
+	if err != nil {
+		goto end
+	}
+	query, args, err = c.bind(ps, args)
+	if err != nil {
+		goto end
+	}
+	result, err = e.ExecContext(ctx, query, args)
+end:
+	return result, err
+}
+
+// bind binds ps with the named arguments, returning the rewritten SQL and
+// its arguments converted for the wrapped connection.
+func (c *shimConn) bind(ps ParsedSQL, args []driver.NamedValue) (query string, out []driver.NamedValue, err error) {
+	var bs BoundSQL
+
+	values := make(map[string]any, len(args))
+	for _, nv := range args {
+		if nv.Name == "" {
+			err = NewErr(ErrUnnamedArgument, "ordinal", nv.Ordinal)
+			goto end
+		}
+		values[nv.Name] = nv.Value
+	}
+	err = checkArgsUsed(ps, args)
+	if err != nil {
+		goto end
+	}
+	bs, err = ps.Bind(values)
+	if err != nil {
+		goto end
+	}
+	out = make([]driver.NamedValue, len(bs.Args))
+	for i, arg := range bs.Args {
+		out[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+		if na, ok := arg.(sql.NamedArg); ok {
+			out[i].Name, out[i].Value = na.Name, na.Value
+		}
+		err = c.convert(&out[i])
+		if err != nil {
+			out = nil
+			goto end
+		}
+	}
+	query = string(bs.SQL)
+end:
+	return query, out, err
+}
+
+// checkArgsUsed reports the named arguments no placeholder or conditional
+// block of ps refers to, including every one when the query has no :name
+// placeholders at all.
+func checkArgsUsed(ps ParsedSQL, args []driver.NamedValue) (err error) {
+	var errs []error
+	var name Selector
+	var root Namespace
+
+	used := make(map[string]bool, len(ps.parameters))
+	for _, p := range ps.parameters {
+		name = p.Name
+		if p.Parent != "" {
+			name = p.Parent
+		}
+		root, _ = splitNamespace(name)
+		used[string(root)] = true
+	}
+	for _, cond := range ps.conditions {
+		root, _ = splitNamespace(cond.token.Name)
+		used[string(root)] = true
+	}
+	for _, nv := range args {
+		if used[nv.Name] {
+			continue
+		}
+		errs = append(errs, NewErr(ErrUnusedArgument, "name", nv.Name))
+	}
+	return CombineErrs(errs)
+}
+
+func hasNamedArg(args []driver.NamedValue) bool {
+	for _, nv := range args {
+		if nv.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping checks the wrapped connection, if it can be pinged.
+func (c *shimConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *shimConn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *shimConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// shimStmt binds a parsed template's named arguments for its prepared
+// statement, or runs the template unprepared when stmt is nil.
+type shimStmt struct {
+	conn *shimConn
+	ps   ParsedSQL
+	stmt driver.Stmt
+}
+
+func (s *shimStmt) Close() (err error) {
+	if s.stmt != nil {
+		err = s.stmt.Close()
+	}
+	return err
+}
+
+// NumInput returns -1, since named arguments are counted when bound.
+func (s *shimStmt) NumInput() int {
+	return -1
+}
+
+func (s *shimStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *shimStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *shimStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	var query string
+	var stmt driver.Stmt
+
+	query, args, err = s.conn.bind(s.ps, args)
+	if err != nil {
+		goto end
+	}
+	stmt = s.stmt
+	if stmt == nil {
+		if e, ok := s.conn.conn.(driver.ExecerContext); ok {
+			result, err = e.ExecContext(ctx, query, args)
+			goto end
+		}
+		stmt, err = s.conn.prepare(ctx, query)
+		if err != nil {
+			goto end
+		}
+		defer func() { _ = stmt.Close() }()
+	}
+	if se, ok := stmt.(driver.StmtExecContext); ok {
+		result, err = se.ExecContext(ctx, args)
+		goto end
+	}
+	result, err = stmt.Exec(driverValues(args))
+end:
+	return result, err
+}
+
+func (s *shimStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	var query string
+	var stmt driver.Stmt
+
+	query, args, err = s.conn.bind(s.ps, args)
+	if err != nil {
+		goto end
+	}
+	if s.stmt != nil {
+		rows, err = queryStmt(ctx, s.stmt, args)
+		goto end
+	}
+	if q, ok := s.conn.conn.(driver.QueryerContext); ok {
+		rows, err = q.QueryContext(ctx, query, args)
+		goto end
+	}
+	// The statement prepared for this call is closed with its rows
+	stmt, err = s.conn.prepare(ctx, query)
+	if err != nil {
+		goto end
+	}
+	rows, err = queryStmt(ctx, stmt, args)
+	if err != nil {
+		_ = stmt.Close()
+		goto end
+	}
+	rows = &stmtRows{Rows: rows, stmt: stmt}
+end:
+	return rows, err
+}
+
+func queryStmt(ctx context.Context, stmt driver.Stmt, args []driver.NamedValue) (driver.Rows, error) {
+	if sq, ok := stmt.(driver.StmtQueryContext); ok {
+		return sq.QueryContext(ctx, args)
+	}
+	return stmt.Query(driverValues(args))
+}
+
+// stmtRows closes the statement it was read from when closed.
+type stmtRows struct {
+	driver.Rows
+	stmt driver.Stmt
+}
+
+// ColumnTypeDatabaseTypeName forwards to the wrapped rows, if they report
+// column types.
+func (r *stmtRows) ColumnTypeDatabaseTypeName(i int) (name string) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		name = ct.ColumnTypeDatabaseTypeName(i)
+	}
+	return name
+}
+
+func (r *stmtRows) Close() error {
+	return CombineErrs([]error{r.Rows.Close(), r.stmt.Close()})
+}
+
+func namedValues(values []driver.Value) (args []driver.NamedValue) {
+	args = make([]driver.NamedValue, len(values))
+	for i, v := range values {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return args
+}
+
+func driverValues(args []driver.NamedValue) (values []driver.Value) {
+	values = make([]driver.Value, len(args))
+	for i, nv := range args {
+		values[i] = nv.Value
+	}
+	return values
+}
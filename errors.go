@@ -23,4 +23,113 @@ var (
 	ErrInvalidRowType = errors.New("invalid row type")
 
 	ErrInvalidDataType = errors.New("invalid data type")
+
+	// ErrMissingParameter indicates that no value was supplied for a parameter
+	// when binding a ParsedSQL.
+	ErrMissingParameter = errors.New("missing parameter value")
+
+	// ErrNativeNameCollision indicates that two different placeholder names
+	// map to the same native name under NamedParamStyle, e.g. :user.id and
+	// :user_id both becoming user_id.
+	ErrNativeNameCollision = errors.New("placeholder names collide as native names")
+
+	// ErrNamedFormatterMismatch indicates that a NamedParamStyle formatter
+	// renders something other than the Selector's NativeName, e.g. @user.id,
+	// so its placeholders would not match the sql.NamedArg values Bind
+	// returns.
+	ErrNamedFormatterMismatch = errors.New("named formatter does not render native names")
+
+	// ErrInvalidSpreadValue indicates that a spread placeholder (:ids...) or
+	// row template (:items(...)) was bound to a value that is not a slice or
+	// array.
+	ErrInvalidSpreadValue = errors.New("spread parameter value is not a slice")
+
+	// ErrEmptySpreadValue indicates that a row template was bound to an empty
+	// slice, or a spread placeholder was under ErrorOnEmptySlice.
+	ErrEmptySpreadValue = errors.New("spread parameter value is empty")
+
+	// ErrInvalidRowTemplate indicates a malformed row template such as an
+	// unclosed or nested :items(...).
+	ErrInvalidRowTemplate = errors.New("invalid row template")
+
+	// ErrTooManyParameters indicates that binding would exceed
+	// ParseSQLArgs.MaxParams driver arguments.
+	ErrTooManyParameters = errors.New("too many bind parameters")
+
+	// ErrConflictingDefault indicates that occurrences of one parameter
+	// declare different default values, e.g. :limit?=50 and :limit?=100.
+	ErrConflictingDefault = errors.New("conflicting parameter defaults")
+
+	// ErrInvalidConditional indicates a malformed conditional block, such as
+	// an unclosed /*{if :name}*/, an unmatched /*{end}*/, or a block that
+	// crosses a row template's parentheses.
+	ErrInvalidConditional = errors.New("invalid conditional block")
+
+	// ErrConflictingDataType indicates that occurrences of one parameter
+	// declare different data types, e.g. :id:integer and :id:string.
+	ErrConflictingDataType = errors.New("conflicting parameter data types")
+
+	// ErrInvalidConstraint indicates a constraint in ParseSQLArgs.Constraints
+	// that cannot be applied, such as one with an invalid Pattern or for a
+	// parameter the template does not use.
+	ErrInvalidConstraint = errors.New("invalid parameter constraint")
+
+	// ErrConstraintViolation indicates a bound value outside a parameter's
+	// declared constraints. Its metadata holds the parameter name, the
+	// constraint, its limit and the value.
+	ErrConstraintViolation = errors.New("parameter constraint violated")
+
+	// ErrInvalidTransform indicates a transform that cannot be registered,
+	// such as one with an invalid or already registered name, or a template
+	// that uses one that is not registered.
+	ErrInvalidTransform = errors.New("invalid parameter transform")
+
+	// ErrConflictingTransforms indicates that occurrences of one parameter
+	// declare different transforms, e.g. :email:string|lower and :email:string|upper.
+	ErrConflictingTransforms = errors.New("conflicting parameter transforms")
+
+	// ErrTransformFailed indicates that a transform rejected a bound value,
+	// e.g. lower applied to a number.
+	ErrTransformFailed = errors.New("parameter transform failed")
+
+	// ErrUnknownNamespace indicates a selector whose root, such as path in
+	// :path.id, has no resolver.
+	ErrUnknownNamespace = errors.New("unknown parameter namespace")
+
+	// ErrUnsupportedContentType indicates a request body whose media type
+	// BindRequest is not configured to read.
+	ErrUnsupportedContentType = errors.New("unsupported request content type")
+
+	// ErrInvalidRequestBody indicates a request body that is too large or
+	// cannot be decoded.
+	ErrInvalidRequestBody = errors.New("invalid request body")
+
+	// ErrInvalidContextKey indicates a context key that cannot be
+	// registered, such as one with an invalid or already registered name.
+	ErrInvalidContextKey = errors.New("invalid context key")
+
+	// ErrUnknownContextKey indicates a :ctx.* parameter whose name no
+	// context key was registered for.
+	ErrUnknownContextKey = errors.New("unknown context key")
+
+	// ErrDuplicateDriver indicates a driver name RegisterDriver cannot use
+	// because database/sql already has a driver by that name.
+	ErrDuplicateDriver = errors.New("driver already registered")
+
+	// ErrUnnamedArgument indicates a positional argument passed to a
+	// wrapped driver alongside sql.NamedArg values, which it cannot place.
+	ErrUnnamedArgument = errors.New("unnamed argument for named placeholders")
+
+	// ErrUnusedArgument indicates a sql.NamedArg passed to a wrapped driver
+	// that no placeholder of the query refers to, which would otherwise be
+	// silently dropped.
+	ErrUnusedArgument = errors.New("named argument not used by query")
+
+	// ErrEmptyResult indicates a query with cardinality one or many that
+	// returned no rows. Errors wrapping it also match sql.ErrNoRows.
+	ErrEmptyResult = errors.New("query returned no rows")
+
+	// ErrTooManyRows indicates a query with cardinality one or one? that
+	// returned more than one row.
+	ErrTooManyRows = errors.New("query returned more than one row")
 )
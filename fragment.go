@@ -0,0 +1,98 @@
+package sqlparams
+
+import (
+	"sort"
+)
+
+// fragmentKind distinguishes the spans of a template that binding treats
+// differently.
+type fragmentKind int
+
+const (
+	paramFragment fragmentKind = iota
+	rowsFragment
+	conditionalFragment
+)
+
+// fragment is a span of the template that binding replaces: a placeholder,
+// a row template, or a conditional block. Row templates and conditional
+// blocks contain the fragments within their body.
+type fragment struct {
+	kind      fragmentKind
+	token     QueryToken // the placeholder, row template or block condition
+	start     int        // span in the template
+	end       int
+	bodyStart int // rows and conditionals: the span rendered around children
+	bodyEnd   int
+	children  []fragment
+}
+
+// conditional is a /*{if :name}*/ ... /*{end}*/ block. Its token spans the
+// opening marker.
+type conditional struct {
+	token   QueryToken
+	bodyEnd int // offset of the /*{end}*/ marker
+	end     int // offset just past the /*{end}*/ marker
+}
+
+// buildFragments nests tokens and conditional blocks into the tree binding
+// walks. Blocks that cross a row template's parentheses are rejected.
+func buildFragments(tokens QueryTokens, conds []conditional) (frags []fragment, err error) {
+	all := make([]fragment, 0, len(tokens)+len(conds))
+	for _, t := range tokens {
+		f := fragment{kind: paramFragment, token: t, start: t.Start, end: t.End}
+		if t.Rows {
+			f.kind = rowsFragment
+			f.bodyStart = t.Start + len(t.Name) + 2 // past ":name("
+			f.bodyEnd = t.End - 1                   // the closing ')'
+		}
+		all = append(all, f)
+	}
+	for _, c := range conds {
+		all = append(all, fragment{
+			kind:      conditionalFragment,
+			token:     c.token,
+			start:     c.token.Start,
+			end:       c.end,
+			bodyStart: c.token.End,
+			bodyEnd:   c.bodyEnd,
+		})
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].start < all[j].start
+	})
+	frags, _, err = nestFragments(all, 0, -1)
+	return frags, err
+}
+
+// nestFragments collects the fragments of all, sorted by start, that begin
+// before limit, nesting each container's children under it. A negative
+// limit means the end of the template.
+func nestFragments(all []fragment, i, limit int) (frags []fragment, next int, err error) {
+	for i < len(all) {
+		f := all[i]
+		if limit >= 0 && f.start >= limit {
+			break
+		}
+		if limit >= 0 && f.end > limit {
+			err = NewErr(
+				ErrInvalidConditional,
+				"marker", f.token.Raw,
+				"offset", f.start,
+				"reason", "conditional block crosses a row template's parentheses",
+			)
+			goto end
+		}
+		i++
+		if f.kind != paramFragment {
+			f.children, i, err = nestFragments(all, i, f.bodyEnd)
+			if err != nil {
+				goto end
+			}
+		}
+		frags = append(frags, f)
+	}
+	next = i
+end:
+	return frags, next, err
+}
@@ -5,8 +5,35 @@ import (
 )
 
 type Parameter struct {
-	Name  Selector
-	Index int
+	Name   Selector
+	Index  int
+	Spread bool     // bound to a slice that expands into a placeholder list
+	Parent Selector // row template whose elements Name is resolved against
+
+	// Optional parameters bind Default (NULL when nil) when no value is
+	// supplied, as declared by :status? or :limit?=50.
+	Optional bool
+	Default  any
+
+	// DataType is the type declared by an annotation such as :id:integer.
+	// Bind coerces values to it. It is empty for untyped parameters.
+	DataType DBDataType
+
+	// Transforms lists the transforms Bind applies to the value, in order,
+	// as declared by :email:string|lower|trim.
+	Transforms TransformChain
+
+	// Sensitive parameters have their values redacted wherever sqlparams
+	// formats them, as declared by :password:string|sensitive or matched by
+	// ParseSQLArgs.SensitiveNames.
+	Sensitive bool
+}
+
+// String returns the parameter's name, with the spread suffix if it has one
+// and qualified by its row template if it is a field of one, e.g.
+// items(...).product_id.
+func (p Parameter) String() string {
+	return paramKey(p.Name, p.Parent, p.Spread)
 }
 
 func (p Parameter) IsIdentifier() bool {
@@ -29,6 +56,19 @@ func NewParameter(name Selector, index int) Parameter {
 	}
 }
 
+// Indexes returns the index of every parameter named name, in index order.
+// It has more than one entry only when parsed with ParseSQLArgs.DisableDedupe.
+func (ps Parameters) Indexes(name Selector) (indexes []int) {
+	indexes = make([]int, 0, 1)
+	for _, p := range ps {
+		if p.Name != name {
+			continue
+		}
+		indexes = append(indexes, p.Index)
+	}
+	return indexes
+}
+
 // Identifiers extracts slice of Identifier from a Parameters value (a
 // slice of []Parameter)
 func (ps Parameters) Identifiers() (ids []Identifier) {
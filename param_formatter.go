@@ -0,0 +1,26 @@
+package sqlparams
+
+// FormatTokenFunc renders the native placeholder for a parameter occurrence,
+// for ParseSQLWithTokenFunc. Unlike FormatParamFunc it receives the full
+// QueryToken, so it can emit name-preserving placeholders. Named placeholders
+// must render the Selector's NativeName, which Bind uses for sql.NamedArg:
+//
+//	SQL Server: func(t QueryToken) string { return "@" + string(t.Name.NativeName()) }
+//	Oracle:     func(t QueryToken) string { return ":" + string(t.Name.NativeName()) }
+//	SQLite:     func(t QueryToken) string { return "$" + string(t.Name.NativeName()) }
+//
+// NamedFormatter returns these.
+type FormatTokenFunc = func(QueryToken) string
+
+// indexTokenFormatter adapts a FormatParamFunc to a FormatTokenFunc,
+// returning nil when formatFunc is nil.
+func indexTokenFormatter(formatFunc FormatParamFunc) (f FormatTokenFunc) {
+	if formatFunc == nil {
+		goto end
+	}
+	f = func(t QueryToken) string {
+		return formatFunc(t.Index)
+	}
+end:
+	return f
+}
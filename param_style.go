@@ -0,0 +1,83 @@
+package sqlparams
+
+import (
+	"strings"
+)
+
+// ParamStyle describes how the placeholders emitted by a FormatParamFunc or
+// FormatTokenFunc map onto driver arguments.
+type ParamStyle string
+
+const (
+	// UnspecifiedParamStyle asks ParseSQL to infer the style from the
+	// formatter.
+	UnspecifiedParamStyle ParamStyle = ""
+
+	// IndexedParamStyle placeholders ($1, @p1, :param1) refer to an argument
+	// by index, so a repeated parameter is bound once.
+	IndexedParamStyle ParamStyle = "indexed"
+
+	// PositionalParamStyle placeholders (?) consume the next argument, so a
+	// repeated parameter must be bound once per occurrence.
+	PositionalParamStyle ParamStyle = "positional"
+
+	// NamedParamStyle placeholders (@items_0_id) refer to an argument by
+	// native name, so each parameter is bound once as a sql.NamedArg whose
+	// name is the Selector's NativeName.
+	NamedParamStyle ParamStyle = "named"
+)
+
+// inferParamStyle treats a formatter that renders the same text for
+// different parameters (e.g. "?") as positional, one whose output depends on
+// the parameter name (e.g. "@email") as named, and anything else as indexed.
+// checkNamedFormatter then rejects named formatters that do not render the
+// names Bind uses.
+func inferParamStyle(formatFunc FormatTokenFunc) (style ParamStyle) {
+	first := formatFunc(QueryToken{Name: "a", Index: 1, Occurrence: 1})
+	if first == formatFunc(QueryToken{Name: "b", Index: 2, Occurrence: 1}) {
+		style = PositionalParamStyle
+		goto end
+	}
+	if first != formatFunc(QueryToken{Name: "b", Index: 1, Occurrence: 1}) {
+		style = NamedParamStyle
+		goto end
+	}
+	style = IndexedParamStyle
+end:
+	return style
+}
+
+// nativeNameProbe is a Selector whose NativeName differs from its text.
+const nativeNameProbe Selector = "a.b[0]"
+
+// checkNamedFormatter reports a NamedParamStyle formatter that renders
+// placeholders other than NativeName, as NamedFormatter does, e.g. one
+// rendering "@" + string(t.Name).
+func checkNamedFormatter(formatFunc FormatTokenFunc) (err error) {
+	rendered := formatFunc(QueryToken{Name: nativeNameProbe, Index: 1, Occurrence: 1})
+	if strings.Contains(rendered, string(nativeNameProbe.NativeName())) {
+		goto end
+	}
+	err = NewErr(
+		ErrNamedFormatterMismatch,
+		"name", nativeNameProbe,
+		"native_name", nativeNameProbe.NativeName(),
+		"rendered", rendered,
+	)
+end:
+	return err
+}
+
+// EmptySliceBehavior controls how Bind handles an empty slice bound to a
+// spread placeholder (:ids...).
+type EmptySliceBehavior string
+
+const (
+	// ErrorOnEmptySlice fails the bind with ErrEmptySpreadValue. This is the
+	// default because IN () is a syntax error in most databases.
+	ErrorOnEmptySlice EmptySliceBehavior = ""
+
+	// NullOnEmptySlice binds a single NULL, so IN (:ids...) becomes IN (NULL)
+	// and matches no rows.
+	NullOnEmptySlice EmptySliceBehavior = "null"
+)
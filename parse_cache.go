@@ -0,0 +1,195 @@
+package sqlparams
+
+import (
+	"container/list"
+	"reflect"
+	"regexp"
+	"sync"
+	"unsafe"
+)
+
+// DefaultParseCacheSize is the number of templates the parse cache of a DB
+// created by NewDB holds.
+const DefaultParseCacheSize = 512
+
+// ParseCache is a concurrency-safe, size-bounded LRU cache of ParsedSQL,
+// keyed by template, dialect and ParseSQLArgs, so services that run the same
+// templates on every request parse each only once.
+//
+// Dialects and args are told apart by their settings, with formatter and
+// cast functions, NativeTypes, Constraints and SensitiveNames compared by
+// identity, so one cache can serve PostgresDialect, PostgresDialect.WithCasts()
+// and differing args without mixing their results. Each entry keeps the
+// dialect and args it was parsed with, so a map built per call gets its own
+// entry rather than one left by a collected map. Maps in a dialect or args
+// must not be modified once used with a cache.
+type ParseCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[parseCacheKey]*list.Element
+	order   *list.List // most recently used first
+	stats   ParseCacheStats
+}
+
+type parseCacheKey struct {
+	config parseConfig
+	sql    SQLQuery
+}
+
+// parseConfig is the comparable identity of a dialect and args: everything
+// that changes how a template parses. Functions and maps are identified by
+// address, which is only sound while something keeps them alive, so each
+// cache entry retains the dialect and args its key was made from: no other
+// map or closure can take their address while the entry is cached.
+type parseConfig struct {
+	dialect     string
+	format      uintptr
+	style       ParamStyle
+	maxParams   int
+	nativeTypes uintptr
+	cast        uintptr
+	castParams  bool
+
+	argsStyle     ParamStyle
+	dedupe        bool
+	emptySlice    EmptySliceBehavior
+	argsMaxParams int
+	constraints   uintptr
+	sensitive     *regexp.Regexp
+}
+
+// newParseConfig returns the identity of d parsing with args.
+func newParseConfig(d Dialect, args []ParseSQLArgs) (pc parseConfig) {
+	pc = parseConfig{
+		dialect:     d.Name,
+		format:      funcIdentity(d.FormatTokenFunc),
+		style:       d.ParamStyle,
+		maxParams:   d.MaxParams,
+		nativeTypes: reflect.ValueOf(d.NativeTypes).Pointer(),
+		cast:        funcIdentity(d.CastFunc),
+		castParams:  d.CastParams,
+	}
+	if len(args) == 0 {
+		goto end
+	}
+	pc.argsStyle = args[0].ParamStyle
+	pc.dedupe = args[0].DisableDedupe
+	pc.emptySlice = args[0].EmptySlice
+	pc.argsMaxParams = args[0].MaxParams
+	pc.constraints = reflect.ValueOf(args[0].Constraints).Pointer()
+	pc.sensitive = args[0].SensitiveNames
+end:
+	return pc
+}
+
+// funcIdentity returns the address of the closure a func value refers to,
+// which unlike reflect's code pointer tells apart closures that share code,
+// such as IndexFormatter("$%d") and IndexFormatter("@p%d"). Zero means nil.
+// Like the addresses of maps, it identifies fn only while fn is reachable;
+// see parseConfig.
+func funcIdentity[F any](fn F) uintptr {
+	return *(*uintptr)(unsafe.Pointer(&fn))
+}
+
+// parseCacheEntry is a cached parse. It retains the dialect and args the
+// template was parsed with, keeping alive the functions and maps its key
+// identifies by address.
+type parseCacheEntry struct {
+	key     parseCacheKey
+	dialect Dialect
+	args    ParseSQLArgs
+	ps      ParsedSQL
+}
+
+// ParseCacheStats counts a ParseCache's lookups and evictions.
+type ParseCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Len       int // templates currently cached
+	Size      int // most templates the cache holds
+}
+
+// NewParseCache returns a cache holding up to size templates. A size of
+// zero or less means DefaultParseCacheSize.
+func NewParseCache(size int) *ParseCache {
+	if size <= 0 {
+		size = DefaultParseCacheSize
+	}
+	return &ParseCache{
+		size:    size,
+		entries: make(map[parseCacheKey]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// ParseSQL returns the cached parse of sqlText for d, parsing it with
+// d.ParseSQL and caching the result on a miss. Failed parses are not cached.
+func (c *ParseCache) ParseSQL(d Dialect, sqlText SQLQuery, args ...ParseSQLArgs) (ps ParsedSQL, err error) {
+	var entry *parseCacheEntry
+
+	key := parseCacheKey{config: newParseConfig(d, args), sql: sqlText}
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		c.stats.Hits++
+		c.order.MoveToFront(e)
+		ps = e.Value.(*parseCacheEntry).ps
+		c.mu.Unlock()
+		goto end
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Parse outside the lock; concurrent misses for one key parse twice
+	// and the later result is kept.
+	ps, err = d.ParseSQL(sqlText, args...)
+	if err != nil {
+		goto end
+	}
+	entry = &parseCacheEntry{key: key, dialect: d, ps: ps}
+	if len(args) > 0 {
+		entry.args = args[0]
+	}
+	c.add(entry)
+end:
+	return ps, err
+}
+
+func (c *ParseCache) add(entry *parseCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[entry.key]
+	if ok {
+		// The cached entry already retains what the key identifies
+		e.Value.(*parseCacheEntry).ps = entry.ps
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*parseCacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// Stats returns the cache's counters.
+func (c *ParseCache) Stats() (stats ParseCacheStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats = c.stats
+	stats.Len = c.order.Len()
+	stats.Size = c.size
+	return stats
+}
+
+// Clear removes every cached template, keeping the counters.
+func (c *ParseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.order.Init()
+}
@@ -1,6 +1,8 @@
 package sqlparams
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -14,10 +16,17 @@ type parseState struct {
 	edits   []editState
 	order   []string
 	indexOf map[string]int
+	seen    map[string]int
 	tokens  QueryTokens
+	dedupe  bool
+	dynamic bool
+	rowsAt  int // index in tokens of the open row template, or -1
+	depth   int // parenthesis depth inside the open row template
+	conds   []conditional
+	open    []int // indexes in conds of unclosed conditional blocks
 }
 
-func newParseState(sqlText SQLQuery) parseState {
+func newParseState(sqlText SQLQuery, args ParseSQLArgs) parseState {
 	return parseState{
 		src:     string(sqlText),
 		n:       len(sqlText),
@@ -25,18 +34,28 @@ func newParseState(sqlText SQLQuery) parseState {
 		edits:   make([]editState, 0),
 		order:   make([]string, 0),
 		indexOf: make(map[string]int),
+		seen:    make(map[string]int),
 		tokens:  make([]QueryToken, 0),
+		dedupe:  !args.DisableDedupe,
+		rowsAt:  -1,
 	}
 }
 
+// editState replaces src[start:end] with repl or, when token is not
+// negative, with the formatted placeholder of tokens[token]. Placeholders are
+// formatted by buildSQL, after the merges have settled every occurrence's
+// data type.
 type editState struct {
 	start, end int
 	repl       string
+	token      int
 }
 
 func (s *parseState) getIndex(name string) (idx int) {
 	var ok bool
-	idx, ok = s.indexOf[name]
+	if s.dedupe {
+		idx, ok = s.indexOf[name]
+	}
 	if ok {
 		goto end
 	}
@@ -151,6 +170,77 @@ end:
 	return
 }
 
+// consumeConditional scans a block comment that may be a conditional block
+// marker, /*{if :name}*/ or /*{end}*/. Markers are ordinary comments to the
+// database, so a template still runs as-is with every block included. Other
+// comments are skipped as usual.
+func (s *parseState) consumeConditional() (err error) {
+	var body, name string
+	var c *conditional
+	var token QueryToken
+	var end int
+
+	start := s.i
+	closeAt := strings.Index(s.src[start+2:], "*/")
+	if closeAt < 0 {
+		s.consumeBlockComment()
+		goto end
+	}
+	end = start + 2 + closeAt + len("*/")
+	body = s.src[start+2 : end-2]
+	if !strings.HasSuffix(body, "}") {
+		s.consumeBlockComment()
+		goto end
+	}
+	body = strings.TrimSpace(body[1 : len(body)-1])
+
+	switch {
+	case body == "end":
+		if len(s.open) == 0 {
+			err = NewErr(
+				ErrInvalidConditional,
+				"marker", s.src[start:end],
+				"offset", start,
+				"reason", "end without a matching if",
+			)
+			goto end
+		}
+		c = &s.conds[s.open[len(s.open)-1]]
+		s.open = s.open[:len(s.open)-1]
+		c.bodyEnd = start
+		c.end = end
+	case body == "if" || strings.HasPrefix(body, "if ") || strings.HasPrefix(body, "if:"):
+		name = strings.TrimSpace(body[2:])
+		if !strings.HasPrefix(name, ":") || !isValidName(name[1:]) {
+			err = NewErr(
+				ErrInvalidConditional,
+				"marker", s.src[start:end],
+				"offset", start,
+				"reason", "expected /*{if :name}*/",
+			)
+			goto end
+		}
+		token = QueryToken{
+			Name:  Selector(name[1:]),
+			Start: start,
+			End:   end,
+			Raw:   s.src[start:end],
+		}
+		if s.rowsAt >= 0 {
+			token.Parent = s.tokens[s.rowsAt].Name
+		}
+		s.open = append(s.open, len(s.conds))
+		s.conds = append(s.conds, conditional{token: token})
+		s.dynamic = true
+	default:
+		s.consumeBlockComment()
+		goto end
+	}
+	s.i = end
+end:
+	return err
+}
+
 func (s *parseState) consumeDollarQuoted() {
 	var tag string
 	var idx int
@@ -242,9 +332,10 @@ end:
 	return
 }
 
-func (s *parseState) consumePlaceholder(formatFunc FormatParamFunc) (err error) {
-	var idx int
+func (s *parseState) consumePlaceholder() (err error) {
+	var token QueryToken
 	var rawName string
+	var spread bool
 
 	start := s.i // Points to ':'
 	s.i++        // Move past ':'
@@ -262,6 +353,11 @@ func (s *parseState) consumePlaceholder(formatFunc FormatParamFunc) (err error)
 	}
 
 	rawName = s.src[s.i:j]
+	spread = strings.HasSuffix(rawName, spreadSuffix)
+	if spread {
+		rawName = strings.TrimSuffix(rawName, spreadSuffix)
+		s.dynamic = true
+	}
 	if !isValidName(rawName) {
 		err = NewErr(
 			ErrInvalidPlaceholderName,
@@ -271,25 +367,344 @@ func (s *parseState) consumePlaceholder(formatFunc FormatParamFunc) (err error)
 		goto end
 	}
 
-	idx = s.getIndex(rawName)
-	s.tokens = append(s.tokens, QueryToken{
-		Name:  Selector(rawName),
-		Index: idx,
-		Start: start,
-		End:   j,
-		Raw:   s.src[start:j],
-	})
+	if !spread && j < s.n && s.src[j] == '(' {
+		err = s.openRowTemplate(start, j, rawName)
+		goto end
+	}
+
+	s.seen[rawName]++
+	token = QueryToken{
+		Name:       Selector(rawName),
+		Occurrence: s.seen[rawName],
+		Start:      start,
+		Spread:     spread,
+	}
+	j = s.consumeDataType(j, &token)
+	j, err = s.consumeTransforms(j, &token)
+	if err != nil {
+		goto end
+	}
+	j = s.consumeDefault(j, &token)
+	token.End = j
+	token.Raw = s.src[start:j]
+	if s.rowsAt >= 0 {
+		token.Parent = s.tokens[s.rowsAt].Name
+	}
+	token.Index = s.getIndex(token.key())
 	s.edits = append(s.edits, editState{
 		start: start,
 		end:   j,
-		repl:  formatFunc(idx),
+		token: len(s.tokens),
 	})
+	s.tokens = append(s.tokens, token)
 	s.i = j
 end:
 	return err
 }
 
-func (s *parseState) buildSQL() SQLQuery {
+// consumeDataType scans a type annotation (:id:integer, :note:string?,
+// :ids:uuid[])
+// following the placeholder name at offset j. A single colon introduces it,
+// so :id::integer remains a cast, and a word that is not a DBDataType is left
+// alone, so :lo:hi is still two placeholders; a type name such as date or
+// time is not, so :a:date is one annotated placeholder. A NULL-able type
+// accepts NULL but does not make the parameter optional: that still needs '?'
+// or a default (:note:string?=null).
+func (s *parseState) consumeDataType(j int, token *QueryToken) (end int) {
+	var dt DBDataType
+	var err error
+
+	end = j
+	if j+1 >= s.n || s.src[j] != ':' || !isValidIdentifierStart(s.src[j+1]) {
+		goto end
+	}
+	end = j + 1
+	for end < s.n && isLetterDigitOrUnderscore(rune(s.src[end])) {
+		end++
+	}
+	if end < s.n && s.src[end] == '?' {
+		end++
+	}
+	if strings.HasPrefix(s.src[end:], arraySuffix) {
+		end += len(arraySuffix)
+		if end < s.n && s.src[end] == '?' {
+			end++
+		}
+	}
+	dt, err = ParseDBDataType(s.src[j+1 : end])
+	if err != nil {
+		end = j
+		goto end
+	}
+	token.DataType = dt
+end:
+	return end
+}
+
+// consumeTransforms scans pipe-style transforms (:email:string|lower|trim)
+// following a type annotation that ends at offset j. Without an annotation
+// '|' is always SQL, so :a||'%' and a bitwise :flags|mask stay SQL. After
+// one, which is never SQL, every |name is a transform and an unknown name is
+// an error, so what a template means never depends on what happens to be
+// registered.
+func (s *parseState) consumeTransforms(j int, token *QueryToken) (end int, err error) {
+	var k int
+	var name string
+
+	end = j
+	if token.DataType == "" {
+		goto end
+	}
+	for end+1 < s.n && s.src[end] == '|' && isValidIdentifierStart(s.src[end+1]) {
+		k = end + 1
+		for k < s.n && isLetterDigitOrUnderscore(rune(s.src[k])) {
+			k++
+		}
+		name = s.src[end+1 : k]
+		end = k
+		if name == sensitiveAnnotation {
+			token.Sensitive = true
+			continue
+		}
+		if _, ok := lookupTransform(name); !ok {
+			err = NewErr(
+				ErrInvalidTransform,
+				"name", token.Name,
+				"transform", name,
+				"offset", token.Start,
+				"reason", "transform is not registered",
+			)
+			goto end
+		}
+		token.Transforms = token.Transforms.append(name)
+	}
+end:
+	return end, err
+}
+
+// mergeTransforms gives every occurrence of a parameter the transforms any
+// one occurrence declares, since they share one bound value. Two different
+// lists for one parameter are an error.
+func (s *parseState) mergeTransforms() (err error) {
+	var errs []error
+	declared := make(map[string]QueryToken)
+	for _, t := range s.tokens {
+		if t.Transforms == "" {
+			continue
+		}
+		other, ok := declared[t.key()]
+		if !ok {
+			declared[t.key()] = t
+			continue
+		}
+		if other.Transforms != t.Transforms {
+			errs = append(errs, NewErr(
+				ErrConflictingTransforms,
+				"name", t.Name,
+				"transforms", t.Transforms,
+				"other", other.Transforms,
+				"offset", t.Start,
+			))
+		}
+	}
+	for i, t := range s.tokens {
+		d, ok := declared[t.key()]
+		if !ok {
+			continue
+		}
+		s.tokens[i].Transforms = d.Transforms
+	}
+	return CombineErrs(errs)
+}
+
+// markSensitive marks the tokens whose name matches names sensitive, then
+// every occurrence of a parameter any one occurrence is sensitive for.
+func (s *parseState) markSensitive(names *regexp.Regexp) {
+	sensitive := make(map[string]bool)
+	for i, t := range s.tokens {
+		if names.MatchString(string(t.Name)) {
+			s.tokens[i].Sensitive = true
+		}
+		if s.tokens[i].Sensitive {
+			sensitive[t.key()] = true
+		}
+	}
+	for i, t := range s.tokens {
+		if sensitive[t.key()] {
+			s.tokens[i].Sensitive = true
+		}
+	}
+}
+
+// mergeDataTypes gives every occurrence of a parameter the data type any one
+// occurrence declares. Two different types for one parameter are an error.
+func (s *parseState) mergeDataTypes() (err error) {
+	var errs []error
+	declared := make(map[string]QueryToken)
+	for _, t := range s.tokens {
+		if t.DataType == "" {
+			continue
+		}
+		other, ok := declared[t.key()]
+		if !ok {
+			declared[t.key()] = t
+			continue
+		}
+		if other.DataType != t.DataType {
+			errs = append(errs, NewErr(
+				ErrConflictingDataType,
+				"name", t.Name,
+				"data_type", t.DataType,
+				"other", other.DataType,
+				"offset", t.Start,
+			))
+		}
+	}
+	for i, t := range s.tokens {
+		d, ok := declared[t.key()]
+		if !ok {
+			continue
+		}
+		s.tokens[i].DataType = d.DataType
+		if d.Optional {
+			s.tokens[i].Optional = true
+		}
+	}
+	return CombineErrs(errs)
+}
+
+// consumeDefault scans an optional marker (:status?) or a default value
+// (:limit?=50) following the placeholder name at offset j. After a type
+// annotation, which is never SQL, the '?' may be dropped (:limit:integer=50).
+// Otherwise a bare '=' is always SQL, so :active=1 and :mode='x' remain
+// comparisons. A default must be a number, a single-quoted string, or true,
+// false or null; anything else after '=' is left as SQL.
+func (s *parseState) consumeDefault(j int, token *QueryToken) (end int) {
+	var value any
+	var marked, ok bool
+
+	end = j
+	if j < s.n && s.src[j] == '?' {
+		marked = true
+		token.Optional = true
+		j++
+		end = j
+	}
+	if j >= s.n || s.src[j] != '=' || token.Spread {
+		goto end
+	}
+	if !marked && token.DataType == "" {
+		goto end
+	}
+	value, j, ok = scanDefaultLiteral(s.src, j+1)
+	if !ok {
+		goto end
+	}
+	token.Optional = true
+	token.Default = value
+	end = j
+end:
+	return end
+}
+
+// mergeDefaults makes every occurrence of a parameter optional, with the
+// same default, when any one occurrence declares it. Two different defaults
+// for one parameter are an error.
+func (s *parseState) mergeDefaults() (err error) {
+	var errs []error
+	declared := make(map[string]QueryToken)
+	for _, t := range s.tokens {
+		if !t.Optional {
+			continue
+		}
+		other, ok := declared[t.key()]
+		if !ok || (!other.hasDefault() && t.hasDefault()) {
+			declared[t.key()] = t
+			continue
+		}
+		if t.hasDefault() && other.Default != t.Default {
+			errs = append(errs, NewErr(
+				ErrConflictingDefault,
+				"name", t.Name,
+				"default", t.Default,
+				"other", other.Default,
+				"offset", t.Start,
+			))
+		}
+	}
+	for i, t := range s.tokens {
+		d, ok := declared[t.key()]
+		if !ok {
+			continue
+		}
+		s.tokens[i].Optional = true
+		s.tokens[i].Default = d.Default
+	}
+	return CombineErrs(errs)
+}
+
+// openRowTemplate starts a row template such as :items(:product_id, :qty).
+// The template's token has no index of its own; its parentheses are rendered
+// once per element at bind time. At parse time it renders as a single row.
+func (s *parseState) openRowTemplate(start, paren int, name string) (err error) {
+	if s.rowsAt >= 0 {
+		err = NewErr(
+			ErrInvalidRowTemplate,
+			"name", name,
+			"offset", start,
+			"reason", "row templates cannot be nested",
+		)
+		goto end
+	}
+	s.seen[name]++
+	s.rowsAt = len(s.tokens)
+	s.depth = 0
+	s.dynamic = true
+	s.tokens = append(s.tokens, QueryToken{
+		Name:       Selector(name),
+		Occurrence: s.seen[name],
+		Start:      start,
+		End:        paren + 1,
+		Raw:        s.src[start : paren+1],
+		Rows:       true,
+	})
+	s.edits = append(s.edits, editState{
+		start: start,
+		end:   paren + 1,
+		repl:  "(",
+		token: -1,
+	})
+	s.i = paren + 1
+end:
+	return err
+}
+
+// closeParen tracks parentheses inside an open row template and closes the
+// template at its matching ')'.
+func (s *parseState) closeParen() {
+	var t *QueryToken
+	if s.rowsAt < 0 {
+		goto end
+	}
+	if s.depth > 0 {
+		s.depth--
+		goto end
+	}
+	t = &s.tokens[s.rowsAt]
+	t.End = s.i + 1
+	t.Raw = s.src[t.Start:t.End]
+	s.rowsAt = -1
+end:
+	return
+}
+
+func (s *parseState) openParen() {
+	if s.rowsAt >= 0 {
+		s.depth++
+	}
+}
+
+func (s *parseState) buildSQL(formatFunc FormatTokenFunc) SQLQuery {
 	var b strings.Builder
 	var last int
 
@@ -298,6 +713,9 @@ func (s *parseState) buildSQL() SQLQuery {
 		if e.start > last {
 			b.WriteString(s.src[last:e.start])
 		}
+		if e.token >= 0 {
+			e.repl = formatFunc(s.tokens[e.token])
+		}
 		b.WriteString(e.repl)
 		last = e.end
 	}
@@ -316,10 +734,91 @@ func (s *parseState) orderedTokens() QueryTokens {
 		if p.Index > len(s.order) {
 			continue
 		}
-		if s.order[p.Index-1] != string(p.Name) {
+		if s.order[p.Index-1] != p.key() {
 			continue
 		}
 		ordered[p.Index-1] = p
 	}
 	return ordered
 }
+
+// scanDefaultLiteral reads a default value literal starting at offset i.
+func scanDefaultLiteral(src string, i int) (value any, end int, ok bool) {
+	var sb strings.Builder
+	var j int
+
+	if i >= len(src) {
+		goto end
+	}
+	switch c := src[i]; {
+	case c == '\'':
+		for j = i + 1; j < len(src); j++ {
+			if src[j] != '\'' {
+				sb.WriteByte(src[j])
+				continue
+			}
+			if j+1 < len(src) && src[j+1] == '\'' {
+				sb.WriteByte('\'')
+				j++
+				continue
+			}
+			value, end, ok = sb.String(), j+1, true
+			goto end
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		value, end, ok = scanNumber(src, i)
+	case isValidIdentifierStart(c):
+		for j = i; j < len(src) && isLetterDigitOrUnderscore(rune(src[j])); j++ {
+		}
+		switch strings.ToLower(src[i:j]) {
+		case "null":
+			value, end, ok = nil, j, true
+		case "true":
+			value, end, ok = true, j, true
+		case "false":
+			value, end, ok = false, j, true
+		}
+	}
+end:
+	return value, end, ok
+}
+
+// scanNumber reads an integer (as int64) or decimal (as float64) literal.
+func scanNumber(src string, i int) (value any, end int, ok bool) {
+	var err error
+	var isFloat bool
+
+	j := i
+	if j < len(src) && src[j] == '-' {
+		j++
+	}
+	digits := j
+	for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+		j++
+	}
+	if j == digits {
+		goto end
+	}
+	if j+1 < len(src) && src[j] == '.' && src[j+1] >= '0' && src[j+1] <= '9' {
+		isFloat = true
+		j++
+		for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			j++
+		}
+	}
+	if j < len(src) && isLetterDigitOrUnderscore(rune(src[j])) {
+		// e.g. :a?=1x is not a number literal
+		goto end
+	}
+	if isFloat {
+		value, err = strconv.ParseFloat(src[i:j], 64)
+	} else {
+		value, err = strconv.ParseInt(src[i:j], 10, 64)
+	}
+	if err != nil {
+		goto end
+	}
+	end, ok = j, true
+end:
+	return value, end, ok
+}
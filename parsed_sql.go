@@ -1,6 +1,7 @@
 package sqlparams
 
 import (
+	"regexp"
 	"unicode"
 )
 
@@ -8,8 +9,17 @@ var _ ParsedQuery = (*ParsedSQL)(nil)
 
 type ParsedSQL struct {
 	SQL         SQLQuery
-	parameters  []Parameter  // ordered by first appearance, deduped by Name
-	occurrences []QueryToken // all parameter occurrences including duplicates
+	parameters  []Parameter             // ordered by first appearance, deduped by Name
+	occurrences []QueryToken            // all parameter occurrences including duplicates
+	style       ParamStyle              // how placeholders in SQL map onto driver arguments
+	template    SQLQuery                // original SQL, re-rendered at bind time when dynamic
+	format      FormatTokenFunc         // formatter used to re-render placeholders
+	dynamic     bool                    // true when binding must re-render the SQL
+	emptySlice  EmptySliceBehavior      // how an empty slice bound to :name... is handled
+	maxParams   int                     // driver argument limit; 0 means unlimited
+	fragments   []fragment              // placeholders, row templates and conditional blocks, nested
+	conditions  []conditional           // conditional blocks in SQL order
+	constraints map[Selector]constraint // ParseSQLArgs.Constraints, compiled
 }
 
 func NewParsedSQL(SQL SQLQuery, parameters []Parameter) ParsedSQL {
@@ -52,27 +62,121 @@ func (ps ParsedSQL) Occurrences() (tokens QueryTokens) {
 	return ps.occurrences
 }
 
-type ParseSQLArgs struct{}
+// Conditions returns the names tested by /*{if :name}*/ blocks, in order of
+// first appearance.
+func (ps ParsedSQL) Conditions() (names []Selector) {
+	seen := make(map[Selector]bool, len(ps.conditions))
+	for _, c := range ps.conditions {
+		if seen[c.token.Name] {
+			continue
+		}
+		seen[c.token.Name] = true
+		names = append(names, c.token.Name)
+	}
+	return names
+}
+
+// ParamStyle reports whether SQL expects one argument per unique parameter
+// (indexed) or one per occurrence (positional). ParsedSQL values not produced
+// by ParseSQL are treated as indexed.
+func (ps ParsedSQL) ParamStyle() ParamStyle {
+	if ps.style == UnspecifiedParamStyle {
+		return IndexedParamStyle
+	}
+	return ps.style
+}
+
+// ParseSQLArgs holds optional settings for ParseSQL.
+type ParseSQLArgs struct {
+	// ParamStyle declares how the formatter's placeholders are bound. When
+	// unspecified it is inferred: a formatter that renders the same text for
+	// every parameter is positional, anything else is indexed.
+	ParamStyle ParamStyle
+
+	// DisableDedupe gives every occurrence of a repeated name its own index,
+	// for drivers and proxies that cannot bind one index in several places.
+	// Parameters() then lists the name once per index.
+	DisableDedupe bool
+
+	// EmptySlice controls what Bind does when a spread placeholder (:ids...)
+	// receives an empty slice. The default, ErrorOnEmptySlice, fails with
+	// ErrEmptySpreadValue.
+	EmptySlice EmptySliceBehavior
+
+	// MaxParams is the most driver arguments one statement may bind. Bind
+	// fails with ErrTooManyParameters beyond it, and BindBatches splits row
+	// templates (:items(...)) across statements to stay within it. Zero
+	// means unlimited.
+	MaxParams int
+
+	// Constraints declares ranges, lengths, patterns, enums and sizes that
+	// Bind checks each named parameter's value against, after coercing it
+	// to its data type. Every violation is reported as an
+	// ErrConstraintViolation, combined into one error.
+	Constraints map[Selector]Constraint
+
+	// SensitiveNames matches the names of parameters whose values are
+	// redacted in errors and in formatted BoundSQL, in addition to those
+	// annotated :name:type|sensitive. Nil means DefaultSensitiveNames.
+	SensitiveNames *regexp.Regexp
+}
+
+// sensitiveNames returns SensitiveNames or its default.
+func (args ParseSQLArgs) sensitiveNames() *regexp.Regexp {
+	if args.SensitiveNames == nil {
+		return DefaultSensitiveNames
+	}
+	return args.SensitiveNames
+}
 
 // ParseSQL finds :name placeholders OUTSIDE of strings/identifiers/comments,
-// rewrites them via FormatParamFunc, and returns the rewritten SQL & ordered tokens.
+// rewrites them via formatFunc, and returns the rewritten SQL & ordered tokens.
 // Supports dotted paths like :user.id and array indices like :items[0].id.
 // Does NOT match PostgreSQL :: casts or standalone : characters.
 //
-// FormatParamFunc examples:
+// formatFunc receives the parameter index:
 //
 //	Postgres: func(i int) string { return fmt.Sprintf("$%d", i) }
 //	MySQL/SQLite: func(int) string { return "?" }
 //	SQL Server: func(i int) string { return fmt.Sprintf("@p%d", i) }
-func ParseSQL(sqlText SQLQuery, formatFunc FormatParamFunc) (ps ParsedSQL, err error) {
+//
+// An optional ParseSQLArgs may be passed to override inferred settings.
+func ParseSQL(sqlText SQLQuery, formatFunc FormatParamFunc, args ...ParseSQLArgs) (ParsedSQL, error) {
+	return ParseSQLWithTokenFunc(sqlText, indexTokenFormatter(formatFunc), args...)
+}
+
+// ParseSQLWithTokenFunc is ParseSQL for a FormatTokenFunc, which receives
+// the full QueryToken rather than only its index:
+//
+//	SQL Server: NamedFormatter("@")
+func ParseSQLWithTokenFunc(sqlText SQLQuery, formatFunc FormatTokenFunc, args ...ParseSQLArgs) (ps ParsedSQL, err error) {
+	var opts ParseSQLArgs
+	if len(args) > 0 {
+		opts = args[0]
+	}
+	return parseSQL(sqlText, formatFunc, opts)
+}
+
+func parseSQL(sqlText SQLQuery, formatFunc FormatTokenFunc, opts ParseSQLArgs) (ps ParsedSQL, err error) {
 	var state parseState
+	var frags []fragment
 
 	if formatFunc == nil {
 		err = ErrFormatParamFuncRequired
 		goto end
 	}
 
-	state = newParseState(sqlText)
+	if opts.ParamStyle == UnspecifiedParamStyle {
+		opts.ParamStyle = inferParamStyle(formatFunc)
+	}
+	if opts.ParamStyle == NamedParamStyle {
+		err = checkNamedFormatter(formatFunc)
+		if err != nil {
+			goto end
+		}
+	}
+
+	state = newParseState(sqlText, opts)
 
 	for state.i < state.n {
 		c := state.src[state.i]
@@ -88,6 +192,13 @@ func ParseSQL(sqlText SQLQuery, formatFunc FormatParamFunc) (ps ParsedSQL, err e
 			state.consumeHashComment()
 			continue
 		case '/':
+			if state.peek(1) == '*' && state.peek(2) == '{' {
+				err = state.consumeConditional()
+				if err != nil {
+					goto end
+				}
+				continue
+			}
 			if state.peek(1) == '*' {
 				state.consumeBlockComment()
 				continue
@@ -110,6 +221,10 @@ func ParseSQL(sqlText SQLQuery, formatFunc FormatParamFunc) (ps ParsedSQL, err e
 		case 'q', 'Q':
 			state.consumeOracleQ()
 			continue
+		case '(':
+			state.openParen()
+		case ')':
+			state.closeParen()
 		case ':':
 			// Skip PostgreSQL :: cast operator
 			if state.peek(1) == ':' {
@@ -118,7 +233,7 @@ func ParseSQL(sqlText SQLQuery, formatFunc FormatParamFunc) (ps ParsedSQL, err e
 			}
 			// Only consume if next char is valid identifier start
 			if state.i+1 < state.n && isValidIdentifierStart(state.src[state.i+1]) {
-				err = state.consumePlaceholder(formatFunc)
+				err = state.consumePlaceholder()
 				if err != nil {
 					goto end
 				}
@@ -129,25 +244,130 @@ func ParseSQL(sqlText SQLQuery, formatFunc FormatParamFunc) (ps ParsedSQL, err e
 		state.i++
 	}
 
+	if state.rowsAt >= 0 {
+		err = NewErr(
+			ErrInvalidRowTemplate,
+			"name", state.tokens[state.rowsAt].Name,
+			"offset", state.tokens[state.rowsAt].Start,
+			"reason", "row template is not closed",
+		)
+		goto end
+	}
+
+	if len(state.open) > 0 {
+		c := state.conds[state.open[len(state.open)-1]]
+		err = NewErr(
+			ErrInvalidConditional,
+			"marker", c.token.Raw,
+			"offset", c.token.Start,
+			"reason", "conditional block is not closed",
+		)
+		goto end
+	}
+
+	state.markSensitive(opts.sensitiveNames())
+
+	err = state.mergeDataTypes()
+	if err != nil {
+		goto end
+	}
+
+	err = state.mergeTransforms()
+	if err != nil {
+		goto end
+	}
+
+	err = state.mergeDefaults()
+	if err != nil {
+		goto end
+	}
+
+	frags, err = buildFragments(state.tokens, state.conds)
+	if err != nil {
+		goto end
+	}
+
 	if len(state.edits) == 0 {
 		ps = NewParsedSQLWithOccurrences(
 			SQLQuery(state.src),
 			state.tokens.Parameters(),
 			state.tokens,
 		)
+		ps.setBindArgs(sqlText, formatFunc, opts)
+		ps.dynamic = state.dynamic
+		ps.fragments = frags
+		ps.conditions = state.conds
+		ps.constraints, err = compileConstraints(opts.Constraints, ps.parameters)
+		if err != nil {
+			ps = ParsedSQL{}
+		}
 		goto end
 	}
 
 	ps = NewParsedSQLWithOccurrences(
-		state.buildSQL(),
+		state.buildSQL(formatFunc),
 		state.orderedTokens().Parameters(),
 		state.tokens,
 	)
+	ps.setBindArgs(sqlText, formatFunc, opts)
+	ps.dynamic = state.dynamic
+	ps.fragments = frags
+	ps.conditions = state.conds
+	ps.constraints, err = compileConstraints(opts.Constraints, ps.parameters)
+	if err != nil {
+		ps = ParsedSQL{}
+		goto end
+	}
+	if ps.style != NamedParamStyle {
+		goto end
+	}
+	err = checkNativeNames(ps.parameters)
+	if err != nil {
+		ps = ParsedSQL{}
+	}
 
 end:
 	return ps, err
 }
 
+// setBindArgs records what Bind needs to re-render the template.
+func (ps *ParsedSQL) setBindArgs(template SQLQuery, formatFunc FormatTokenFunc, opts ParseSQLArgs) {
+	ps.style = opts.ParamStyle
+	ps.template = template
+	ps.format = formatFunc
+	ps.emptySlice = opts.EmptySlice
+	ps.maxParams = opts.MaxParams
+}
+
+// checkNativeNames reports distinct parameter names that would be bound
+// under the same NativeName.
+func checkNativeNames(params []Parameter) (err error) {
+	var errs []error
+	names := make(map[Identifier]Selector, len(params))
+	for _, p := range params {
+		if p.Parent != "" {
+			// Row template fields are renamed per element at bind time
+			continue
+		}
+		native := p.Name.NativeName()
+		other, ok := names[native]
+		if !ok {
+			names[native] = p.Name
+			continue
+		}
+		if other == p.Name {
+			continue
+		}
+		errs = append(errs, NewErr(
+			ErrNativeNameCollision,
+			"name", p.Name,
+			"other", other,
+			"native_name", native,
+		))
+	}
+	return CombineErrs(errs)
+}
+
 func isValidName(s string) (is bool) {
 	var i int
 	if s == "" {
@@ -0,0 +1,58 @@
+package sqlparams
+
+import (
+	"context"
+	"time"
+)
+
+// QueryHook is called after each named call of a DB, and of its
+// transactions and connections, e.g. to log or trace queries. See
+// WithQueryHook.
+type QueryHook func(ctx context.Context, event QueryEvent)
+
+// QueryEvent describes a named call to a QueryHook. Hooks never see the
+// values of sensitive parameters: Args holds the bound arguments as
+// BoundSQL.RedactedArgs returns them.
+type QueryEvent struct {
+	// Template is the SQL as passed to the call, with :name placeholders.
+	Template SQLQuery
+
+	// SQL is the rewritten SQL that was run, empty when parsing or binding
+	// failed.
+	SQL SQLQuery
+
+	// Args are the bound arguments with sensitive values redacted.
+	Args []any
+
+	// Duration is the time spent binding and running the call; for
+	// QueryRowNamed, up to the row being ready to Scan, and for QueryNamed,
+	// up to the first row.
+	Duration time.Duration
+
+	// Err is the parse, bind or driver error the call returned, if any.
+	Err error
+}
+
+// WithQueryHook returns a copy of db that calls hook after each named call,
+// including those of transactions and connections begun from the copy. A
+// nil hook removes it.
+func (db *DB) WithQueryHook(hook QueryHook) *DB {
+	clone := *db
+	clone.hook = hook
+	return &clone
+}
+
+// report calls nq's hook, if any, for a call of query that started at
+// start.
+func (nq namedQueryer) report(ctx context.Context, query SQLQuery, bs BoundSQL, start time.Time, err error) {
+	if nq.hook == nil {
+		return
+	}
+	nq.hook(ctx, QueryEvent{
+		Template: query,
+		SQL:      bs.SQL,
+		Args:     bs.RedactedArgs(),
+		Duration: time.Since(start),
+		Err:      err,
+	})
+}
@@ -5,11 +5,43 @@ import (
 )
 
 type QueryToken struct {
-	Name  Selector // logical name: e.g., "path.accountId" or "body.items.0.id"
-	Index int      // assigned parameter index (1-based)
-	Start int      // byte offset start in original SQL
-	End   int      // byte offset end (exclusive)
-	Raw   string   // full token, e.g. "{user.id}"
+	Name       Selector       // logical name: e.g., "path.accountId" or "body.items.0.id"
+	Index      int            // assigned parameter index (1-based)
+	Occurrence int            // occurrence number of Name within the SQL (1-based)
+	Start      int            // byte offset start in original SQL
+	End        int            // byte offset end (exclusive)
+	Raw        string         // full token, e.g. "{user.id}"
+	Spread     bool           // true for :name..., which expands a slice at bind time
+	Rows       bool           // true for a :name(...) row template spanning Start to End
+	Parent     Selector       // name of the enclosing row template, if any
+	Optional   bool           // true for :name? or :name?=default; missing binds Default
+	Default    any            // declared default: int64, float64, string, bool or nil
+	DataType   DBDataType     // declared by :name:type, e.g. :id:integer; empty when untyped
+	Transforms TransformChain // declared by :name:type|transform, e.g. :email:string|lower|trim
+	Sensitive  bool           // declared by :name:type|sensitive or matched by ParseSQLArgs.SensitiveNames
+}
+
+// hasDefault reports whether the token declares a non-NULL default.
+func (qt QueryToken) hasDefault() bool {
+	return qt.Default != nil
+}
+
+// key identifies the parameter a token binds to; a spread and a plain
+// occurrence of the same name are different parameters, as are fields of a
+// row template and top-level names.
+func (qt QueryToken) key() string {
+	return paramKey(qt.Name, qt.Parent, qt.Spread)
+}
+
+func paramKey(name, parent Selector, spread bool) (key string) {
+	key = string(name)
+	if spread {
+		key += spreadSuffix
+	}
+	if parent != "" {
+		key = string(parent) + "(" + spreadSuffix + ")." + key
+	}
+	return key
 }
 
 type QueryTokens []QueryToken
@@ -22,6 +54,13 @@ func (qts QueryTokens) Parameters() (names []Parameter) {
 	})
 	for i, sp := range qts {
 		names[i] = NewParameter(sp.Name, sp.Index)
+		names[i].Spread = sp.Spread
+		names[i].Parent = sp.Parent
+		names[i].Optional = sp.Optional
+		names[i].Default = sp.Default
+		names[i].DataType = sp.DataType
+		names[i].Transforms = sp.Transforms
+		names[i].Sensitive = sp.Sensitive
 	}
 	return names
 }
@@ -0,0 +1,68 @@
+package sqlparams
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+)
+
+// sensitiveAnnotation marks a parameter sensitive when written as a
+// transform, e.g. :password:string|sensitive.
+const sensitiveAnnotation = "sensitive"
+
+// RedactedValue replaces the values of sensitive parameters wherever
+// sqlparams formats them.
+const RedactedValue = "[REDACTED]"
+
+// DefaultSensitiveNames matches parameter names that commonly hold secrets
+// or personal data, such as password, api_key, auth.token or user.ssn.
+var DefaultSensitiveNames = regexp.MustCompile(`(?i)passw(or)?d|secret|token|api_?key|credential|(^|[^a-z])ssn([^a-z]|$)`)
+
+// IsSensitive reports whether the argument at index i binds a sensitive
+// parameter.
+func (bs BoundSQL) IsSensitive(i int) bool {
+	return i >= 0 && i < len(bs.sensitive) && bs.sensitive[i]
+}
+
+// RedactedArgs returns a copy of Args with the values of sensitive
+// parameters replaced by RedactedValue; sql.NamedArg keeps its name.
+func (bs BoundSQL) RedactedArgs() (args []any) {
+	args = make([]any, len(bs.Args))
+	for i, arg := range bs.Args {
+		switch {
+		case !bs.IsSensitive(i):
+			args[i] = arg
+		case isNamedArg(arg):
+			args[i] = sql.Named(arg.(sql.NamedArg).Name, RedactedValue)
+		default:
+			args[i] = RedactedValue
+		}
+	}
+	return args
+}
+
+func isNamedArg(arg any) bool {
+	_, ok := arg.(sql.NamedArg)
+	return ok
+}
+
+// String renders the SQL and its arguments for debugging and logs, with
+// sensitive values redacted.
+func (bs BoundSQL) String() string {
+	return fmt.Sprintf("%s %v", bs.SQL, bs.RedactedArgs())
+}
+
+// Format renders bs as String does for every verb, so that %#v and %+v
+// cannot print sensitive values either.
+func (bs BoundSQL) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(bs.String()))
+}
+
+// LogValue renders bs for log/slog as its SQL and redacted arguments.
+func (bs BoundSQL) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("sql", string(bs.SQL)),
+		slog.Any("args", bs.RedactedArgs()),
+	)
+}
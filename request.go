@@ -0,0 +1,257 @@
+package sqlparams
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the most request body BindRequest reads when
+// RequestArgs.MaxBodyBytes is zero.
+const DefaultMaxBodyBytes = 1 << 20
+
+// DefaultContentTypes are the request body media types BindRequest accepts
+// when RequestArgs.ContentTypes is empty.
+var DefaultContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+}
+
+// RequestArgs configures how BindRequest reads a request.
+type RequestArgs struct {
+	// MaxBodyBytes limits the body read for :body.* parameters. Larger
+	// bodies fail with ErrInvalidRequestBody. Zero means DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+
+	// ContentTypes lists the body media types accepted, e.g.
+	// "application/json". Media types ending in +json are read as JSON.
+	// Other bodies fail with ErrUnsupportedContentType. Empty means
+	// DefaultContentTypes.
+	ContentTypes []string
+
+	// Resolvers adds resolvers for namespaces other than the request's,
+	// such as env, or replaces the request's own.
+	Resolvers NamespacedResolver
+}
+
+// BindRequest binds ps with values from r: :path.* from the ServeMux
+// pattern's wildcards, :query.* from the query string, :header.* from the
+// headers, :body.* from a JSON or form body, and :ctx.* from r.Context().
+// See NewRequestResolver.
+func BindRequest(r *http.Request, ps ParsedSQL, args ...RequestArgs) (bs BoundSQL, err error) {
+	var resolver NamespacedResolver
+
+	resolver, err = NewRequestResolver(r, ps, args...)
+	if err != nil {
+		goto end
+	}
+	bs, err = ps.BindFrom(resolver)
+end:
+	return bs, err
+}
+
+// NewRequestResolver returns a NamespacedResolver for r's path, query,
+// header and body namespaces and, via ContextResolver, r's context, plus any
+// RequestArgs.Resolvers.
+//
+// Query values and headers resolve to a string, or to a []string when sent
+// more than once or bound to a spread placeholder such as :query.ids....
+// Header names are matched with underscores as hyphens, so
+// :header.x_request_id reads X-Request-Id. The body is read, and r.Body
+// consumed, only when ps uses the body namespace; JSON numbers decode as
+// json.Number, which typed parameters coerce without rounding.
+func NewRequestResolver(r *http.Request, ps ParsedSQL, args ...RequestArgs) (resolver NamespacedResolver, err error) {
+	var body ParamResolver
+	var a RequestArgs
+
+	if len(args) > 0 {
+		a = args[0]
+	}
+	spread := requestSpreads(ps)
+	resolver = NamespacedResolver{
+		PathNamespace: ResolverFunc(func(name Selector) (value any, ok bool, err error) {
+			value = r.PathValue(string(name))
+			return value, value != "", err
+		}),
+		QueryNamespace:   valuesResolver{values: r.URL.Query(), spread: spread[QueryNamespace]},
+		HeaderNamespace:  headerResolver{header: r.Header, spread: spread[HeaderNamespace]},
+		ContextNamespace: ContextResolver(r.Context()),
+	}
+	if usesNamespace(ps, BodyNamespace) {
+		body, err = readBody(r, a, spread[BodyNamespace])
+		if err != nil {
+			resolver = nil
+			goto end
+		}
+		resolver[BodyNamespace] = body
+	}
+	for ns, rr := range a.Resolvers {
+		resolver[ns] = rr
+	}
+end:
+	return resolver, err
+}
+
+// requestSpreads returns the names, within their namespace, of the spread
+// parameters of ps.
+func requestSpreads(ps ParsedSQL) (spread map[Namespace]map[Selector]bool) {
+	spread = make(map[Namespace]map[Selector]bool)
+	for _, p := range ps.Parameters() {
+		if !p.Spread || p.Parent != "" {
+			continue
+		}
+		ns, rest := splitNamespace(p.Name)
+		if spread[ns] == nil {
+			spread[ns] = make(map[Selector]bool)
+		}
+		spread[ns][rest] = true
+	}
+	return spread
+}
+
+func usesNamespace(ps ParsedSQL, ns Namespace) bool {
+	for _, p := range ps.Parameters() {
+		if root, _ := splitNamespace(p.Name); root == ns && p.Parent == "" {
+			return true
+		}
+	}
+	for _, name := range ps.Conditions() {
+		if root, _ := splitNamespace(name); root == ns {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads r's body, within a's size limit, into a resolver for its
+// content type.
+func readBody(r *http.Request, a RequestArgs, spread map[Selector]bool) (body ParamResolver, err error) {
+	var data []byte
+	var mediaType string
+	var params map[string]string
+	var form url.Values
+	var decoded any
+
+	limit := a.MaxBodyBytes
+	if limit == 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	types := a.ContentTypes
+	if len(types) == 0 {
+		types = DefaultContentTypes
+	}
+
+	body = MapResolver(nil)
+	if r.Body == nil || r.Body == http.NoBody {
+		goto end
+	}
+	mediaType, params, err = mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !slices.Contains(types, mediaType) {
+		err = NewErr(ErrUnsupportedContentType, "content_type", r.Header.Get("Content-Type"))
+		goto end
+	}
+	data, err = io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		err = NewErr(ErrInvalidRequestBody, err)
+		goto end
+	}
+	if int64(len(data)) > limit {
+		err = NewErr(ErrInvalidRequestBody, "reason", "body too large", "limit", limit)
+		goto end
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if len(bytes.TrimSpace(data)) == 0 {
+			goto end
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&decoded)
+		if err != nil {
+			err = NewErr(ErrInvalidRequestBody, "content_type", mediaType, err)
+			goto end
+		}
+		body = ResolverFunc(func(name Selector) (value any, ok bool, err error) {
+			value, ok = lookupSelector(decoded, name)
+			return value, ok, err
+		})
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err = url.ParseQuery(string(data))
+		if err != nil {
+			err = NewErr(ErrInvalidRequestBody, "content_type", mediaType, err)
+			goto end
+		}
+		body = valuesResolver{values: form, spread: spread}
+	case mediaType == "multipart/form-data":
+		form, err = readMultipart(data, params["boundary"], limit)
+		if err != nil {
+			err = NewErr(ErrInvalidRequestBody, "content_type", mediaType, err)
+			goto end
+		}
+		body = valuesResolver{values: form, spread: spread}
+	default:
+		err = NewErr(ErrUnsupportedContentType, "content_type", mediaType)
+	}
+end:
+	return body, err
+}
+
+// readMultipart returns the non-file fields of a multipart/form-data body.
+func readMultipart(data []byte, boundary string, limit int64) (values url.Values, err error) {
+	var form *multipart.Form
+
+	form, err = multipart.NewReader(bytes.NewReader(data), boundary).ReadForm(limit)
+	if err != nil {
+		goto end
+	}
+	values = url.Values(form.Value)
+	err = form.RemoveAll()
+end:
+	return values, err
+}
+
+// valuesResolver resolves names against url.Values, such as a query string
+// or form body.
+type valuesResolver struct {
+	values url.Values
+	spread map[Selector]bool
+}
+
+func (vr valuesResolver) Resolve(name Selector) (value any, ok bool, err error) {
+	value, ok = multiValue(vr.values[string(name)], vr.spread[name])
+	return value, ok, err
+}
+
+// headerResolver resolves names against headers, reading underscores in
+// names as hyphens.
+type headerResolver struct {
+	header http.Header
+	spread map[Selector]bool
+}
+
+func (hr headerResolver) Resolve(name Selector) (value any, ok bool, err error) {
+	key := strings.ReplaceAll(string(name), "_", "-")
+	value, ok = multiValue(hr.header.Values(key), hr.spread[name])
+	return value, ok, err
+}
+
+// multiValue returns a single value as a string, and repeated values or
+// values bound to a spread placeholder as a []string.
+func multiValue(values []string, spread bool) (value any, ok bool) {
+	switch {
+	case len(values) == 0:
+	case len(values) == 1 && !spread:
+		value, ok = values[0], true
+	default:
+		value, ok = values, true
+	}
+	return value, ok
+}
@@ -0,0 +1,120 @@
+package sqlparams
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// ParamResolver supplies parameter values by name at bind time. Resolve
+// reports ok=false for names it has no value for, which Bind reports as
+// missing, and returns an error for names it refuses to resolve.
+type ParamResolver interface {
+	Resolve(name Selector) (value any, ok bool, err error)
+}
+
+// ResolverFunc adapts a function to a ParamResolver.
+type ResolverFunc func(name Selector) (value any, ok bool, err error)
+
+// Resolve calls fn.
+func (fn ResolverFunc) Resolve(name Selector) (value any, ok bool, err error) {
+	return fn(name)
+}
+
+// MapResolver resolves names against a map, as Bind does: exact keys such as
+// "user.id" first, then by walking nested maps, structs and slices.
+type MapResolver map[string]any
+
+// Resolve looks up name in m.
+func (m MapResolver) Resolve(name Selector) (value any, ok bool, err error) {
+	value, ok = m[string(name)]
+	if ok {
+		goto end
+	}
+	value, ok = lookupSelector(map[string]any(m), name)
+end:
+	return value, ok, err
+}
+
+// Namespace is the root segment of a namespaced selector, e.g. path in
+// :path.accountId.
+type Namespace string
+
+const (
+	PathNamespace    Namespace = "path"
+	QueryNamespace   Namespace = "query"
+	HeaderNamespace  Namespace = "header"
+	BodyNamespace    Namespace = "body"
+	ContextNamespace Namespace = "ctx"
+	EnvNamespace     Namespace = "env"
+)
+
+// NamespacedResolver routes each selector to the resolver for its root
+// segment, passing it the rest of the selector: body.items[0].id is resolved
+// as items[0].id by the body resolver. Names whose root has no resolver fail
+// with ErrUnknownNamespace, so templates can only read the sources a
+// NamespacedResolver is given.
+type NamespacedResolver map[Namespace]ParamResolver
+
+// Resolve routes name to the resolver for its namespace.
+func (nr NamespacedResolver) Resolve(name Selector) (value any, ok bool, err error) {
+	ns, rest := splitNamespace(name)
+	r, found := nr[ns]
+	if !found || r == nil {
+		err = NewErr(ErrUnknownNamespace, "name", name, "namespace", ns)
+		goto end
+	}
+	value, ok, err = r.Resolve(rest)
+end:
+	return value, ok, err
+}
+
+// Validate reports every parameter and condition of ps whose namespace has
+// no resolver, so templates can be rejected when they are loaded rather than
+// when they are first bound. Fields of row templates are not checked, since
+// they resolve against the template's elements.
+func (nr NamespacedResolver) Validate(ps ParsedSQL) (err error) {
+	var errs []error
+	names := ps.Conditions()
+	for _, p := range ps.Parameters() {
+		if p.Parent != "" {
+			continue
+		}
+		names = append(names, p.Name)
+	}
+	for _, name := range names {
+		ns, _ := splitNamespace(name)
+		if nr[ns] != nil {
+			continue
+		}
+		errs = append(errs, NewErr(ErrUnknownNamespace, "name", name, "namespace", ns))
+	}
+	return CombineErrs(errs)
+}
+
+// splitNamespace splits name at the end of its first segment, e.g. path.id
+// into path and id, or body[0].id into body and [0].id.
+func splitNamespace(name Selector) (ns Namespace, rest Selector) {
+	s := string(name)
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return Namespace(s), ""
+	}
+	rest = Selector(strings.TrimPrefix(s[i:], "."))
+	return Namespace(s[:i]), rest
+}
+
+// EnvResolver resolves the environment variables it lists, e.g.
+// EnvResolver{"APP_REGION"} for :env.APP_REGION. Variables not listed are
+// reported as missing, so templates cannot read arbitrary secrets.
+type EnvResolver []string
+
+// Resolve looks up name in the environment when er lists it.
+func (er EnvResolver) Resolve(name Selector) (value any, ok bool, err error) {
+	if !slices.Contains(er, string(name)) {
+		goto end
+	}
+	value, ok = os.LookupEnv(string(name))
+end:
+	return value, ok, err
+}
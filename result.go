@@ -0,0 +1,406 @@
+package sqlparams
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// ResultShape declares the Go shape ReadResult returns rows in.
+//
+// Scalar row types read the only column of each row: integer as int64, real
+// as float64, string as string, json as the column's JSON text, verbatim, in
+// a json.RawMessage, and any as scanned. columns reads every column into a
+// ColumnMap. A NULL in a non-NULL-able type fails with
+// ErrInvalidResultsColumnDataType; NULL-able types read it as an untyped
+// nil, or a nil element of a []json.RawMessage.
+//
+// OneRow and OneRowOrNone return a single value, nil when OneRowOrNone finds
+// no rows. ManyRows and ManyRowsOrNone return a slice: []int64, []float64,
+// []string, []json.RawMessage, []ColumnMap, or []any for any and the other
+// NULL-able scalar types.
+//
+// ColumnTypes, when given, declare each column's data type, e.g. from
+// ParseColumnTypes, and are applied to every row before it is shaped: values
+// are converted as by Coerce, so integers stored as SQLite TEXT read as
+// int64, JSON columns are decoded (except with json and string row types,
+// which read them as text), and NULL, including JSON null, in a
+// non-NULL-able column fails.
+type ResultShape struct {
+	// RowType is the shape of each row. Empty means DefaultRowType.
+	RowType DBRowType
+
+	// Cardinality is the number of rows expected, enforced as by ScanRows.
+	// Empty means DefaultCardinality.
+	Cardinality Cardinality
+
+	// ColumnTypes are the data types of the columns in SELECT order, one per
+	// column. An empty type, or any, leaves its column as scanned.
+	ColumnTypes []DBDataType
+}
+
+// ColumnMap is one row's columns in SELECT order. It marshals to a JSON
+// object with its keys in that order.
+type ColumnMap []ColumnValue
+
+// ColumnValue is one column of a ColumnMap.
+type ColumnValue struct {
+	Name  string
+	Value any
+}
+
+// Get returns the value of the column named name.
+func (cm ColumnMap) Get(name string) (value any, ok bool) {
+	for _, cv := range cm {
+		if cv.Name == name {
+			return cv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON renders cm as a JSON object, keeping its column order.
+func (cm ColumnMap) MarshalJSON() (data []byte, err error) {
+	var b bytes.Buffer
+	var part []byte
+
+	b.WriteByte('{')
+	for i, cv := range cm {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		part, err = json.Marshal(cv.Name)
+		if err != nil {
+			goto end
+		}
+		b.Write(part)
+		b.WriteByte(':')
+		part, err = json.Marshal(cv.Value)
+		if err != nil {
+			goto end
+		}
+		b.Write(part)
+	}
+	b.WriteByte('}')
+	data = b.Bytes()
+end:
+	return data, err
+}
+
+// ReadResult reads rows into the Go shape declared by shape, and closes
+// rows:
+//
+//	name, err := ReadResult(rows, ResultShape{RowType: StringRowType, Cardinality: OneRow})
+//	// name.(string)
+func ReadResult(rows *sql.Rows, shape ResultShape) (result any, err error) {
+	var r resultReader
+	var c Cardinality
+
+	// ScanRows closes rows; this closes them when it is not reached
+	defer func() { _ = rows.Close() }()
+
+	r.rowType, err = ParseDBRowType(string(shape.RowType))
+	if err != nil {
+		goto end
+	}
+	c, err = ParseCardinality(string(shape.Cardinality))
+	if err != nil {
+		goto end
+	}
+	r.columns, err = rows.Columns()
+	if err != nil {
+		goto end
+	}
+	if r.rowType != ColumnsRowType && len(r.columns) != 1 {
+		err = NewErr(
+			ErrInvalidRowType,
+			"row_type", r.rowType,
+			"columns", len(r.columns),
+			"reason", "row type reads exactly one column",
+		)
+		goto end
+	}
+	r.columnTypes, err = r.checkColumnTypes(shape.ColumnTypes)
+	if err != nil {
+		goto end
+	}
+	r.values = make([]any, len(r.columns))
+	r.dest = make([]any, len(r.columns))
+	for i := range r.values {
+		r.dest[i] = &r.values[i]
+	}
+	r.rows = r.newRows()
+
+	_, err = ScanRows(rows, c, r.scan)
+	if err != nil {
+		goto end
+	}
+	result = r.rows
+	if c == OneRow || c == OneRowOrNone {
+		result = r.first
+	}
+end:
+	return result, err
+}
+
+// QueryResult runs query as QueryNamed does and reads its rows with
+// ReadResult.
+func (nq namedQueryer) QueryResult(ctx context.Context, shape ResultShape, query SQLQuery, params any) (result any, err error) {
+	var rows *sql.Rows
+
+	rows, err = nq.QueryNamed(ctx, query, params)
+	if err != nil {
+		goto end
+	}
+	result, err = ReadResult(rows, shape)
+end:
+	return result, err
+}
+
+// resultReader converts the rows ReadResult scans.
+type resultReader struct {
+	rowType     DBRowType
+	columns     []string
+	columnTypes []DBDataType // normalized, or nil
+	values      []any        // the current row, scanned
+	dest        []any        // pointers into values
+	n           int          // rows read
+	first       any          // the first row, shaped
+	rows        any          // every row, shaped, in a slice of the row type's Go type
+}
+
+// checkColumnTypes validates and normalizes the declared column types.
+func (r *resultReader) checkColumnTypes(cts []DBDataType) (normalized []DBDataType, err error) {
+	var errs []error
+
+	if len(cts) == 0 {
+		goto end
+	}
+	if len(cts) != len(r.columns) {
+		err = NewErr(
+			ErrInvalidResultsColumnDataType,
+			"columns", len(r.columns),
+			"column_types", len(cts),
+			"reason", "one column type is needed per column",
+		)
+		goto end
+	}
+	normalized = make([]DBDataType, len(cts))
+	for i, dt := range cts {
+		dt = dt.Normalize()
+		if dt != "" && !dt.valid() {
+			errs = append(errs, NewErr(
+				ErrInvalidDataType,
+				"column", r.columns[i],
+				"data_type", cts[i],
+			))
+			continue
+		}
+		normalized[i] = dt
+	}
+	err = CombineErrs(errs)
+	if err != nil {
+		err = NewErr(ErrInvalidResultsColumnDataType, err)
+	}
+end:
+	return normalized, err
+}
+
+// newRows returns an empty slice of the Go type for r's row type.
+func (r *resultReader) newRows() any {
+	switch r.rowType {
+	case IntegerRowType:
+		return []int64{}
+	case RealRowType:
+		return []float64{}
+	case StringRowType:
+		return []string{}
+	case JSONRowType, JSONRowTypeOrNULL:
+		return []json.RawMessage{}
+	case ColumnsRowType:
+		return []ColumnMap{}
+	}
+	return []any{}
+}
+
+func (r *resultReader) scan(rows *sql.Rows) (err error) {
+	var value any
+
+	err = rows.Scan(r.dest...)
+	if err != nil {
+		goto end
+	}
+	r.n++
+	err = r.convert()
+	if err != nil {
+		goto end
+	}
+	value, err = r.shape()
+	if err != nil {
+		goto end
+	}
+	if r.n == 1 {
+		r.first = value
+	}
+	switch rs := r.rows.(type) {
+	case []int64:
+		r.rows = append(rs, value.(int64))
+	case []float64:
+		r.rows = append(rs, value.(float64))
+	case []string:
+		r.rows = append(rs, value.(string))
+	case []json.RawMessage:
+		// NULL is shaped as an untyped nil, and kept as a nil element
+		raw, _ := value.(json.RawMessage)
+		r.rows = append(rs, raw)
+	case []ColumnMap:
+		r.rows = append(rs, value.(ColumnMap))
+	case []any:
+		r.rows = append(rs, value)
+	}
+end:
+	return err
+}
+
+// convert applies the declared column types to the current row.
+func (r *resultReader) convert() (err error) {
+	var errs []error
+	var reason string
+
+	// json and string row types read JSON columns as text, so they are
+	// validated only
+	decode := true
+	switch r.rowType {
+	case JSONRowType, JSONRowTypeOrNULL, StringRowType, StringRowTypeOrNULL:
+		decode = false
+	}
+	for i, dt := range r.columnTypes {
+		r.values[i], reason = columnValue(r.values[i], dt, decode)
+		if reason == "" {
+			continue
+		}
+		errs = append(errs, NewErr(
+			ErrInvalidResultsColumnDataType,
+			"column", r.columns[i],
+			"row", r.n,
+			"data_type", dt,
+			"reason", reason,
+		))
+	}
+	err = CombineErrs(errs)
+	return err
+}
+
+// columnValue converts a scanned column value to dt, decoding JSON text when
+// decode is set. JSON null counts as NULL.
+func columnValue(value any, dt DBDataType, decode bool) (out any, reason string) {
+	var text string
+	var decoded any
+	var err error
+
+	if b, ok := value.([]byte); ok && trimNullable(dt) != BytesDBDataType {
+		// Text columns of some drivers scan as []byte
+		value = string(b)
+	}
+	out, reason = coerceValue(value, dt)
+	if reason != "" || out == nil || trimNullable(dt) != JSONDBDataType {
+		goto end
+	}
+	text = out.(string)
+	if !dt.Nullable() && strings.TrimSpace(text) == "null" {
+		out, reason = nil, "NULL is not allowed"
+		goto end
+	}
+	if !decode {
+		goto end
+	}
+	err = json.Unmarshal([]byte(text), &decoded)
+	if err != nil {
+		reason = "not valid JSON text"
+		out = nil
+		goto end
+	}
+	out = decoded
+end:
+	return out, reason
+}
+
+// shape converts the current row to r's row type.
+func (r *resultReader) shape() (value any, err error) {
+	var dt DBDataType
+	var reason string
+
+	if r.rowType == ColumnsRowType {
+		cm := make(ColumnMap, len(r.columns))
+		for i, name := range r.columns {
+			cm[i] = ColumnValue{Name: name, Value: r.values[i]}
+		}
+		value = cm
+		goto end
+	}
+
+	value = r.values[0]
+	switch r.rowType {
+	case AnyRowType:
+		goto end
+	case JSONRowType, JSONRowTypeOrNULL:
+		value, reason = shapeJSON(value, r.rowType == JSONRowTypeOrNULL)
+	default:
+		dt = DBDataType(r.rowType)
+		if b, ok := value.([]byte); ok {
+			// Text columns of some drivers scan as []byte
+			value = string(b)
+		}
+		value, reason = coerceValue(value, dt)
+	}
+	if reason != "" {
+		err = NewErr(
+			ErrInvalidResultsColumnDataType,
+			"column", r.columns[0],
+			"row", r.n,
+			"row_type", r.rowType,
+			"reason", reason,
+		)
+	}
+end:
+	return value, err
+}
+
+// shapeJSON returns a JSON column's text as a json.RawMessage, or an untyped
+// nil for NULL when nullable, so a single NULL row reads as nil rather than a
+// nil json.RawMessage.
+func shapeJSON(value any, nullable bool) (shaped any, reason string) {
+	var raw json.RawMessage
+
+	raw, reason = rawJSON(value, nullable)
+	if raw != nil {
+		shaped = raw
+	}
+	return shaped, reason
+}
+
+// rawJSON returns a JSON column's text as a json.RawMessage, or nil for
+// NULL when nullable.
+func rawJSON(value any, nullable bool) (raw json.RawMessage, reason string) {
+	switch v := value.(type) {
+	case nil:
+		if !nullable {
+			reason = "NULL is not allowed"
+		}
+		goto end
+	case []byte:
+		raw = json.RawMessage(v)
+	case string:
+		raw = json.RawMessage(v)
+	default:
+		reason = "not JSON text"
+		goto end
+	}
+	if !json.Valid(raw) {
+		raw, reason = nil, "not valid JSON text"
+	}
+end:
+	return raw, reason
+}
@@ -0,0 +1,67 @@
+package sqlparams
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ScanRows calls scan for each row of rows, enforcing c, and closes rows.
+// It returns the number of rows scanned.
+//
+// OneRow and ManyRows fail with ErrEmptyResult, which also matches
+// sql.ErrNoRows, when there are no rows; OneRowOrNone and ManyRowsOrNone
+// allow none. OneRow and OneRowOrNone fail with ErrTooManyRows when there
+// is a second row, which is never scanned, and read no further.
+func ScanRows(rows *sql.Rows, c Cardinality, scan func(*sql.Rows) error) (n int, err error) {
+	defer func() {
+		err = CombineErrs([]error{err, rows.Close()})
+	}()
+
+	c, err = ParseCardinality(string(c))
+	if err != nil {
+		goto end
+	}
+	for rows.Next() {
+		if n == 1 && (c == OneRow || c == OneRowOrNone) {
+			err = NewErr(ErrTooManyRows, "cardinality", c)
+			goto end
+		}
+		err = scan(rows)
+		if err != nil {
+			goto end
+		}
+		n++
+	}
+	err = rows.Err()
+	if err != nil {
+		goto end
+	}
+	if n == 0 && !c.EmptyOk() {
+		err = NewErr(ErrEmptyResult, "cardinality", c, sql.ErrNoRows)
+	}
+end:
+	return n, err
+}
+
+// QueryRows runs query as QueryNamed does and scans its rows with ScanRows,
+// enforcing c:
+//
+//	var users []User
+//	_, err := db.QueryRows(ctx, sqlparams.ManyRows, "SELECT id, name FROM users WHERE team = :team", params,
+//		func(rows *sql.Rows) error {
+//			var u User
+//			err := rows.Scan(&u.ID, &u.Name)
+//			users = append(users, u)
+//			return err
+//		})
+func (nq namedQueryer) QueryRows(ctx context.Context, c Cardinality, query SQLQuery, params any, scan func(*sql.Rows) error) (n int, err error) {
+	var rows *sql.Rows
+
+	rows, err = nq.QueryNamed(ctx, query, params)
+	if err != nil {
+		goto end
+	}
+	n, err = ScanRows(rows, c, scan)
+end:
+	return n, err
+}
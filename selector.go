@@ -0,0 +1,177 @@
+package sqlparams
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NativeName converts a Selector into an identifier that is legal as a
+// native named placeholder, e.g. items[0].id becomes items_0_id and user.id
+// becomes user_id.
+func (s Selector) NativeName() Identifier {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range string(s) {
+		switch r {
+		case '.', '[':
+			b.WriteByte('_')
+		case ']':
+			// Dropped; the preceding '[' already separated the index
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return Identifier(b.String())
+}
+
+// lookupSelector resolves sel against root, walking maps with string keys,
+// structs (matched by `db` tag, `json` tag or case-insensitive field name),
+// and slices or arrays by [n] index. Pointers and interfaces are followed.
+func lookupSelector(root any, sel Selector) (value any, ok bool) {
+	var name string
+	var index int
+	var isIndex bool
+
+	rv := reflect.ValueOf(root)
+	s := string(sel)
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			continue
+		case '[':
+			j := strings.IndexByte(s[i:], ']')
+			if j < 0 {
+				goto end
+			}
+			n, err := strconv.Atoi(s[i+1 : i+j])
+			if err != nil {
+				goto end
+			}
+			index, isIndex = n, true
+			i += j + 1
+		default:
+			j := strings.IndexAny(s[i:], ".[")
+			if j < 0 {
+				j = len(s) - i
+			}
+			name, isIndex = s[i:i+j], false
+			i += j
+		}
+		if isIndex {
+			rv, ok = indexValue(rv, index)
+		} else {
+			rv, ok = fieldValue(rv, name)
+		}
+		if !ok {
+			goto end
+		}
+	}
+	rv = indirectValue(rv)
+	if !rv.IsValid() {
+		// A nil pointer or interface resolves to a present NULL
+		value, ok = nil, true
+		goto end
+	}
+	value, ok = rv.Interface(), true
+end:
+	return value, ok
+}
+
+// indirectValue follows pointers and interfaces, returning an invalid Value
+// for nil.
+func indirectValue(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func indexValue(rv reflect.Value, index int) (out reflect.Value, ok bool) {
+	rv = indirectValue(rv)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if index < 0 || index >= rv.Len() {
+			goto end
+		}
+		out, ok = rv.Index(index), true
+	}
+end:
+	return out, ok
+}
+
+func fieldValue(rv reflect.Value, name string) (out reflect.Value, ok bool) {
+	rv = indirectValue(rv)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			goto end
+		}
+		out = rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		ok = out.IsValid()
+	case reflect.Struct:
+		out, ok = structField(rv, name)
+	}
+end:
+	return out, ok
+}
+
+// structField finds the exported field whose `db` or `json` tag, or
+// failing that whose name ignoring case, matches name. Fields of embedded
+// structs are searched after the outer struct's own fields, and fields
+// tagged `db:"-"` or `json:"-"` are never matched.
+func structField(rv reflect.Value, name string) (out reflect.Value, ok bool) {
+	var embedded []reflect.Value
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() || hiddenField(f) {
+			continue
+		}
+		if f.Anonymous && indirectValue(rv.Field(i)).Kind() == reflect.Struct {
+			embedded = append(embedded, indirectValue(rv.Field(i)))
+			continue
+		}
+		if tagName(f, "db") == name || tagName(f, "json") == name {
+			out, ok = rv.Field(i), true
+			goto end
+		}
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() || f.Anonymous || hiddenField(f) {
+			continue
+		}
+		if tagName(f, "db") != "" || tagName(f, "json") != "" {
+			continue
+		}
+		if strings.EqualFold(f.Name, name) {
+			out, ok = rv.Field(i), true
+			goto end
+		}
+	}
+	for _, e := range embedded {
+		out, ok = structField(e, name)
+		if ok {
+			goto end
+		}
+	}
+end:
+	return out, ok
+}
+
+func tagName(f reflect.StructField, key string) string {
+	tag, _, _ := strings.Cut(f.Tag.Get(key), ",")
+	return tag
+}
+
+// hiddenField reports whether f is excluded with a `db:"-"` or `json:"-"`
+// tag. As in encoding/json, `json:"-,"` names the field "-" instead.
+func hiddenField(f reflect.StructField) bool {
+	return f.Tag.Get("db") == "-" || f.Tag.Get("json") == "-"
+}
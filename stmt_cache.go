@@ -0,0 +1,240 @@
+package sqlparams
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// DefaultStmtCacheSize is the number of statements a StmtCache holds when
+// created with a size of zero or less.
+const DefaultStmtCacheSize = 128
+
+// StmtCache is a size-bounded LRU cache of prepared statements for one
+// *sql.DB, keyed by rewritten SQL. Statements are prepared on first use and
+// closed when evicted, once no call is still using them. A call that fails
+// with driver.ErrBadConn, after database/sql's own retries, is retried once
+// on a freshly prepared statement.
+//
+// A StmtCache runs the QueryContext, QueryRowContext and ExecContext calls
+// of a DB created with WithStmtCache.
+type StmtCache struct {
+	db      *sql.DB
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	stats   StmtCacheStats
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // calls currently using stmt
+	evicted bool // close stmt once refs drops to zero
+}
+
+// StmtCacheStats counts a StmtCache's lookups, evictions and statements
+// re-prepared after driver.ErrBadConn.
+type StmtCacheStats struct {
+	Hits       uint64
+	Misses     uint64
+	Evictions  uint64
+	Reprepares uint64
+	Len        int // statements currently cached
+	Size       int // most statements the cache holds
+}
+
+// NewStmtCache returns a cache of up to size statements prepared on db. A
+// size of zero or less means DefaultStmtCacheSize.
+func NewStmtCache(db *sql.DB, size int) *StmtCache {
+	if size <= 0 {
+		size = DefaultStmtCacheSize
+	}
+	return &StmtCache{
+		db:      db,
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// QueryContext runs query with the cached statement for it.
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	err = c.run(ctx, query, func(stmt *sql.Stmt) (err error) {
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+// ExecContext runs query with the cached statement for it.
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...any) (result sql.Result, err error) {
+	err = c.run(ctx, query, func(stmt *sql.Stmt) (err error) {
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryRowContext runs query with the cached statement for it. Since a
+// *sql.Row defers its error to Scan, it is not retried after
+// driver.ErrBadConn, and a statement that fails to prepare is run
+// unprepared so that Scan reports why.
+func (c *StmtCache) QueryRowContext(ctx context.Context, query string, args ...any) (row *sql.Row) {
+	e, err := c.acquire(ctx, query)
+	if err != nil {
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	defer c.release(e)
+	return e.stmt.QueryRowContext(ctx, args...)
+}
+
+// run calls fn with the statement for query, re-preparing it and calling fn
+// once more when fn fails with driver.ErrBadConn.
+func (c *StmtCache) run(ctx context.Context, query string, fn func(*sql.Stmt) error) (err error) {
+	var e *stmtEntry
+
+	e, err = c.acquire(ctx, query)
+	if err != nil {
+		goto end
+	}
+	err = fn(e.stmt)
+	c.release(e)
+	if !errors.Is(err, driver.ErrBadConn) {
+		goto end
+	}
+
+	c.discard(e)
+	e, err = c.acquire(ctx, query)
+	if err != nil {
+		goto end
+	}
+	c.mu.Lock()
+	c.stats.Reprepares++
+	c.mu.Unlock()
+	err = fn(e.stmt)
+	c.release(e)
+end:
+	return err
+}
+
+// acquire returns the entry for query, preparing its statement on a miss,
+// and holds it until release.
+func (c *StmtCache) acquire(ctx context.Context, query string) (e *stmtEntry, err error) {
+	var stmt *sql.Stmt
+	var evicted []*sql.Stmt
+
+	c.mu.Lock()
+	el, ok := c.entries[query]
+	if ok {
+		c.stats.Hits++
+		c.order.MoveToFront(el)
+		e = el.Value.(*stmtEntry)
+		e.refs++
+		c.mu.Unlock()
+		goto end
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Prepare outside the lock; when concurrent misses race, the first
+	// statement cached is kept and the others closed.
+	stmt, err = c.db.PrepareContext(ctx, query)
+	if err != nil {
+		goto end
+	}
+	c.mu.Lock()
+	el, ok = c.entries[query]
+	if ok {
+		e = el.Value.(*stmtEntry)
+		e.refs++
+		c.mu.Unlock()
+		_ = stmt.Close()
+		goto end
+	}
+	e = &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		evicted = append(evicted, c.remove(c.order.Back())...)
+		c.stats.Evictions++
+	}
+	c.mu.Unlock()
+	closeStmts(evicted)
+end:
+	return e, err
+}
+
+// release ends a call's use of e, closing its statement if it was evicted
+// meanwhile.
+func (c *StmtCache) release(e *stmtEntry) {
+	c.mu.Lock()
+	e.refs--
+	closeNow := e.evicted && e.refs == 0
+	c.mu.Unlock()
+	if closeNow {
+		_ = e.stmt.Close()
+	}
+}
+
+// discard removes e from the cache, if it is still cached, so the next call
+// prepares a new statement.
+func (c *StmtCache) discard(e *stmtEntry) {
+	var closed []*sql.Stmt
+
+	c.mu.Lock()
+	el, ok := c.entries[e.query]
+	if ok && el.Value.(*stmtEntry) == e {
+		closed = c.remove(el)
+	}
+	c.mu.Unlock()
+	closeStmts(closed)
+}
+
+// remove removes el from the cache under c.mu, returning its statement to
+// close if no call is using it.
+func (c *StmtCache) remove(el *list.Element) (closed []*sql.Stmt) {
+	e := c.order.Remove(el).(*stmtEntry)
+	delete(c.entries, e.query)
+	e.evicted = true
+	if e.refs == 0 {
+		closed = append(closed, e.stmt)
+	}
+	return closed
+}
+
+func closeStmts(stmts []*sql.Stmt) {
+	for _, stmt := range stmts {
+		_ = stmt.Close()
+	}
+}
+
+// Stats returns the cache's counters.
+func (c *StmtCache) Stats() (stats StmtCacheStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats = c.stats
+	stats.Len = c.order.Len()
+	stats.Size = c.size
+	return stats
+}
+
+// Close removes and closes every cached statement. Statements still in use
+// are closed when their calls finish. The cache remains usable.
+func (c *StmtCache) Close() (err error) {
+	var closed []*sql.Stmt
+
+	c.mu.Lock()
+	for c.order.Len() > 0 {
+		closed = append(closed, c.remove(c.order.Front())...)
+	}
+	c.mu.Unlock()
+	errs := make([]error, 0, len(closed))
+	for _, stmt := range closed {
+		errs = append(errs, stmt.Close())
+	}
+	return CombineErrs(errs)
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_Bind(t *testing.T) {
+	postgresFormat := func(i int) string { return fmt.Sprintf("$%d", i) }
+	mysqlFormat := func(int) string { return "?" }
+
+	tests := []struct {
+		name            string
+		sql             sqlparams.SQLQuery
+		formatParamFunc sqlparams.FormatParamFunc
+		args            sqlparams.ParseSQLArgs
+		values          map[string]any
+		expectedStyle   sqlparams.ParamStyle
+		expectedSQL     sqlparams.SQLQuery
+		expectedArgs    []any
+		expectedError   error
+	}{
+		{
+			name:            "indexed style binds repeated parameter once",
+			sql:             "SELECT * FROM orders WHERE created_at >= :since AND updated_at >= :since AND id = :id",
+			formatParamFunc: postgresFormat,
+			values:          map[string]any{"since": "2024-01-01", "id": 42},
+			expectedStyle:   sqlparams.IndexedParamStyle,
+			expectedSQL:     "SELECT * FROM orders WHERE created_at >= $1 AND updated_at >= $1 AND id = $2",
+			expectedArgs:    []any{"2024-01-01", 42},
+		},
+		{
+			name:            "positional style binds repeated parameter per occurrence",
+			sql:             "SELECT * FROM orders WHERE created_at >= :since AND id = :id AND updated_at >= :since",
+			formatParamFunc: mysqlFormat,
+			values:          map[string]any{"since": "2024-01-01", "id": 42},
+			expectedStyle:   sqlparams.PositionalParamStyle,
+			expectedSQL:     "SELECT * FROM orders WHERE created_at >= ? AND id = ? AND updated_at >= ?",
+			expectedArgs:    []any{"2024-01-01", 42, "2024-01-01"},
+		},
+		{
+			name:            "explicit style overrides inference",
+			sql:             "SELECT * FROM t WHERE a = :a OR b = :a",
+			formatParamFunc: func(int) string { return "$1" },
+			args:            sqlparams.ParseSQLArgs{ParamStyle: sqlparams.IndexedParamStyle},
+			values:          map[string]any{"a": 1},
+			expectedStyle:   sqlparams.IndexedParamStyle,
+			expectedSQL:     "SELECT * FROM t WHERE a = $1 OR b = $1",
+			expectedArgs:    []any{1},
+		},
+		{
+			name:            "disabled dedupe binds repeated parameter per index",
+			sql:             "SELECT * FROM orders WHERE created_at >= :since AND id = :id AND updated_at >= :since",
+			formatParamFunc: postgresFormat,
+			args:            sqlparams.ParseSQLArgs{DisableDedupe: true},
+			values:          map[string]any{"since": "2024-01-01", "id": 42},
+			expectedStyle:   sqlparams.IndexedParamStyle,
+			expectedSQL:     "SELECT * FROM orders WHERE created_at >= $1 AND id = $2 AND updated_at >= $3",
+			expectedArgs:    []any{"2024-01-01", 42, "2024-01-01"},
+		},
+		{
+			name:            "no placeholders",
+			sql:             "SELECT 1",
+			formatParamFunc: mysqlFormat,
+			expectedStyle:   sqlparams.PositionalParamStyle,
+			expectedSQL:     "SELECT 1",
+			expectedArgs:    []any{},
+		},
+		{
+			name:            "missing parameter",
+			sql:             "SELECT * FROM users WHERE id = :id AND org = :org_id",
+			formatParamFunc: mysqlFormat,
+			values:          map[string]any{"id": 1},
+			expectedStyle:   sqlparams.PositionalParamStyle,
+			expectedError:   sqlparams.ErrMissingParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.ParseSQL(tt.sql, tt.formatParamFunc, tt.args)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			if parsed.ParamStyle() != tt.expectedStyle {
+				t.Errorf("ParamStyle mismatch: expected %q, got %q", tt.expectedStyle, parsed.ParamStyle())
+			}
+
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_DisableDedupe(t *testing.T) {
+	parsed, err := sqlparams.ParseSQL(
+		"SELECT * FROM t WHERE a = :a OR b = :b OR c = :a",
+		func(i int) string { return fmt.Sprintf("$%d", i) },
+		sqlparams.ParseSQLArgs{DisableDedupe: true},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := sqlparams.Parameters{
+		sqlparams.NewParameter("a", 1),
+		sqlparams.NewParameter("b", 2),
+		sqlparams.NewParameter("a", 3),
+	}
+	if !reflect.DeepEqual(parsed.Parameters(), expected) {
+		t.Errorf("Parameters mismatch:\nexpected: %v\nactual:   %v", expected, parsed.Parameters())
+	}
+	indexes := parsed.Parameters().Indexes("a")
+	if !reflect.DeepEqual(indexes, []int{1, 3}) {
+		t.Errorf("Indexes(a) mismatch: expected [1 3], got %v", indexes)
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestDialect_WithCasts(t *testing.T) {
+	tests := []struct {
+		name         string
+		dialect      sqlparams.Dialect
+		sql          sqlparams.SQLQuery
+		values       map[string]any
+		expectedSQL  sqlparams.SQLQuery
+		expectedArgs []any
+	}{
+		{
+			name:         "casts are off by default",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE id = :id:uuid",
+			values:       map[string]any{"id": "123e4567-e89b-12d3-a456-426614174000"},
+			expectedSQL:  "SELECT * FROM t WHERE id = $1",
+			expectedArgs: []any{"123e4567-e89b-12d3-a456-426614174000"},
+		},
+		{
+			name:         "postgres",
+			dialect:      sqlparams.PostgresDialect.WithCasts(),
+			sql:          "SELECT * FROM t WHERE id = :id:uuid AND meta @> :meta:json? AND tag = ANY(:tags:string?[]) AND n = :n",
+			values:       map[string]any{"id": "123e4567-e89b-12d3-a456-426614174000", "meta": `{}`, "tags": []any{"a"}, "n": 1},
+			expectedSQL:  "SELECT * FROM t WHERE id = $1::uuid AND meta @> $2::jsonb AND tag = ANY($3::text[]) AND n = $4",
+			expectedArgs: []any{"123e4567-e89b-12d3-a456-426614174000", `{}`, []any{"a"}, 1},
+		},
+		{
+			name:         "one annotation casts every occurrence",
+			dialect:      sqlparams.PostgresDialect.WithCasts(),
+			sql:          "SELECT * FROM t WHERE id = :id OR parent = :id:uuid",
+			values:       map[string]any{"id": "123e4567-e89b-12d3-a456-426614174000"},
+			expectedSQL:  "SELECT * FROM t WHERE id = $1::uuid OR parent = $1::uuid",
+			expectedArgs: []any{"123e4567-e89b-12d3-a456-426614174000"},
+		},
+		{
+			name:         "sql server",
+			dialect:      sqlparams.SQLServerDialect.WithCasts(),
+			sql:          "SELECT * FROM t WHERE at > :since:timestamptz AND id IN (:ids...:integer)",
+			values:       map[string]any{"since": "2024-01-02T03:04:05Z", "ids": []float64{1, 2}},
+			expectedSQL:  "SELECT * FROM t WHERE at > CAST(@p1 AS datetimeoffset) AND id IN (CAST(@p2 AS bigint), CAST(@p3 AS bigint))",
+			expectedArgs: []any{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), int64(1), int64(2)},
+		},
+		{
+			name: "overridden type",
+			dialect: sqlparams.PostgresDialect.WithCasts(sqlparams.PostgresNativeTypes.With(sqlparams.NativeTypes{
+				sqlparams.JSONDBDataType: "json",
+				sqlparams.UUIDDBDataType: "",
+			})),
+			sql:          "UPDATE t SET meta = :meta:json WHERE id = :id:uuid",
+			values:       map[string]any{"meta": `[]`, "id": "123e4567e89b12d3a456426614174000"},
+			expectedSQL:  "UPDATE t SET meta = $1::json WHERE id = $2",
+			expectedArgs: []any{`[]`, "123e4567-e89b-12d3-a456-426614174000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.dialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+
+	if _, ok := sqlparams.PostgresNativeTypes[sqlparams.UUIDDBDataType]; !ok {
+		t.Error("With must not modify the map it is called on")
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestCoerce(t *testing.T) {
+	name := "Ann"
+	var nilName *string
+
+	tests := []struct {
+		name          string
+		value         any
+		dataType      sqlparams.DBDataType
+		expected      any
+		expectedError error
+	}{
+		{name: "integer from float64", value: float64(42), dataType: sqlparams.IntegerDBDataType, expected: int64(42)},
+		{name: "integer from json.Number", value: json.Number("7"), dataType: sqlparams.IntDBDataType, expected: int64(7)},
+		{name: "integer from string", value: " 12 ", dataType: sqlparams.IntegerDBDataType, expected: int64(12)},
+		{name: "integer from whole exponent", value: json.Number("1e3"), dataType: sqlparams.IntegerDBDataType, expected: int64(1000)},
+		{name: "integer from uint8", value: uint8(3), dataType: sqlparams.IntegerDBDataType, expected: int64(3)},
+		{name: "fractional integer", value: 1.5, dataType: sqlparams.IntegerDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "integer from word", value: "ten", dataType: sqlparams.IntegerDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "integer from map", value: map[string]any{}, dataType: sqlparams.IntegerDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "real from json.Number", value: json.Number("2.5"), dataType: sqlparams.RealDBDataType, expected: 2.5},
+		{name: "real from int", value: 2, dataType: sqlparams.RealDBDataType, expected: float64(2)},
+		{name: "string from pointer", value: &name, dataType: sqlparams.StringDBDataType, expected: "Ann"},
+		{name: "string from float64", value: 90210.0, dataType: sqlparams.StringDBDataType, expected: "90210"},
+		{name: "string from bool", value: true, dataType: sqlparams.StringDBDataType, expected: "true"},
+		{name: "string from slice", value: []string{"a"}, dataType: sqlparams.StringDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "json from nested map", value: map[string]any{"a": []any{json.Number("1"), "b"}}, dataType: sqlparams.JSONDBDataType, expected: `{"a":[1,"b"]}`},
+		{name: "json from slice", value: []int{1, 2}, dataType: sqlparams.JSONDBDataType, expected: `[1,2]`},
+		{name: "json from text", value: `{"a":1}`, dataType: sqlparams.JSONDBDataType, expected: `{"a":1}`},
+		{name: "json from RawMessage", value: json.RawMessage(`[true]`), dataType: sqlparams.JSONDBDataType, expected: `[true]`},
+		{name: "json from invalid text", value: "hello", dataType: sqlparams.JSONDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "NULL-able accepts nil", value: nil, dataType: sqlparams.JSONDBDataTypeOrNULL, expected: nil},
+		{name: "NULL-able accepts nil pointer", value: nilName, dataType: sqlparams.StringDBDataTypeOrNULL, expected: nil},
+		{name: "NULL-able still coerces", value: float64(5), dataType: sqlparams.IntegerDBDataTypeOrNULL, expected: int64(5)},
+		{name: "nil for non-NULL-able", value: nil, dataType: sqlparams.RealDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "any passes through", value: []int{1}, dataType: sqlparams.AnyDBDataType, expected: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := sqlparams.Coerce(tt.value, tt.dataType)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				kind, ok := sqlparams.ErrValue[string](err, "value_kind")
+				if !ok || kind == "" {
+					t.Errorf("expected value_kind metadata, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsedSQL_BindCoerceErrorNamesParameter(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL("SELECT * FROM t WHERE id = :user.id:integer")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	_, err = parsed.Bind(map[string]any{"user": map[string]any{"id": "abc"}})
+	if !errors.Is(err, sqlparams.ErrInvalidDataType) {
+		t.Fatalf("expected %v, got %v", sqlparams.ErrInvalidDataType, err)
+	}
+	name, _ := sqlparams.ErrValue[sqlparams.Selector](err, "name")
+	if name != "user.id" {
+		t.Errorf("expected name user.id, got %q", name)
+	}
+	kind, _ := sqlparams.ErrValue[string](err, "value_kind")
+	if kind != "string" {
+		t.Errorf("expected value_kind string, got %q", kind)
+	}
+}
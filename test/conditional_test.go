@@ -0,0 +1,161 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindConditional(t *testing.T) {
+	const filters = "SELECT * FROM users WHERE 1=1" +
+		" /*{if :email}*/AND email = :email /*{end}*/" +
+		"/*{if :status}*/AND status = :status /*{end}*/" +
+		"LIMIT :limit"
+
+	tests := []struct {
+		name          string
+		dialect       sqlparams.Dialect
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "all blocks kept",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          filters,
+			values:       map[string]any{"email": "a@example.com", "status": "active", "limit": 10},
+			expectedSQL:  "SELECT * FROM users WHERE 1=1 AND email = $1 AND status = $2 LIMIT $3",
+			expectedArgs: []any{"a@example.com", "active", 10},
+		},
+		{
+			name:         "missing parameter drops its block and renumbers",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          filters,
+			values:       map[string]any{"status": "active", "limit": 10},
+			expectedSQL:  "SELECT * FROM users WHERE 1=1 AND status = $1 LIMIT $2",
+			expectedArgs: []any{"active", 10},
+		},
+		{
+			name:         "nil counts as not supplied",
+			dialect:      sqlparams.MySQLDialect,
+			sql:          filters,
+			values:       map[string]any{"email": nil, "status": (*string)(nil), "limit": 10},
+			expectedSQL:  "SELECT * FROM users WHERE 1=1 LIMIT ?",
+			expectedArgs: []any{10},
+		},
+		{
+			name:    "nested blocks and flags",
+			dialect: sqlparams.PostgresDialect,
+			sql: "SELECT * FROM t WHERE a = :a" +
+				"/*{if :org}*/ AND org = :org/*{if :active}*/ AND active/*{end}*//*{end}*/",
+			values:       map[string]any{"a": 1, "org": 2, "active": true},
+			expectedSQL:  "SELECT * FROM t WHERE a = $1 AND org = $2 AND active",
+			expectedArgs: []any{1, 2},
+		},
+		{
+			name:    "block inside a row template",
+			dialect: sqlparams.PostgresDialect,
+			sql:     "INSERT INTO t (a, b) VALUES :items(:a, /*{if :b}*/:b/*{end}*//*{if :c}*/DEFAULT/*{end}*/)",
+			values: map[string]any{"items": []map[string]any{
+				{"a": 1, "b": 2},
+				{"a": 3, "c": true},
+			}},
+			expectedSQL:  "INSERT INTO t (a, b) VALUES ($1, $2), ($3, DEFAULT)",
+			expectedArgs: []any{1, 2, 3},
+		},
+		{
+			name:          "kept block still requires its parameters",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE 1=1 /*{if :org}*/AND org = :org AND team = :team/*{end}*/",
+			values:        map[string]any{"org": 1},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.dialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_Conditional(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           sqlparams.SQLQuery
+		expectedSQL   sqlparams.SQLQuery
+		expectedError error
+	}{
+		{
+			name:        "markers stay as comments",
+			sql:         "SELECT * FROM t WHERE 1=1 /*{if :email}*/AND email = :email/*{end}*/",
+			expectedSQL: "SELECT * FROM t WHERE 1=1 /*{if :email}*/AND email = $1/*{end}*/",
+		},
+		{
+			name:        "other brace comments are ignored",
+			sql:         "SELECT /*{\"hint\": 1}*/ :a",
+			expectedSQL: "SELECT /*{\"hint\": 1}*/ $1",
+		},
+		{
+			name:          "unclosed",
+			sql:           "SELECT * FROM t WHERE 1=1 /*{if :email}*/AND email = :email",
+			expectedError: sqlparams.ErrInvalidConditional,
+		},
+		{
+			name:          "end without if",
+			sql:           "SELECT * FROM t WHERE a = :a /*{end}*/",
+			expectedError: sqlparams.ErrInvalidConditional,
+		},
+		{
+			name:          "missing name",
+			sql:           "SELECT * FROM t WHERE 1=1 /*{if email}*/AND email = :email/*{end}*/",
+			expectedError: sqlparams.ErrInvalidConditional,
+		},
+		{
+			name:          "crosses a row template",
+			sql:           "INSERT INTO t (a) VALUES /*{if :x}*/:items(:a/*{end}*/)",
+			expectedError: sqlparams.ErrInvalidConditional,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, parsed.SQL)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindConstraints(t *testing.T) {
+	constraints := map[sqlparams.Selector]sqlparams.Constraint{
+		"age":    {Min: sqlparams.Limit(18), Max: sqlparams.Limit(130)},
+		"email":  {MaxLength: 20, Pattern: `^[^@]+@[^@]+$`},
+		"status": {Enum: []any{"active", "disabled"}},
+		"ids":    {MinItems: 1, MaxItems: 3, Min: sqlparams.Limit(1)},
+		"price":  {Min: sqlparams.Limit(0)},
+	}
+	parsed, err := sqlparams.MySQLDialect.ParseSQL(
+		"SELECT * FROM users WHERE age >= :age:integer AND email = :email AND status = :status"+
+			" AND id IN (:ids...) AND price > :price:decimal AND (:status = 'x' OR :age > 0)",
+		sqlparams.ParseSQLArgs{Constraints: constraints},
+	)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	tests := []struct {
+		name                string
+		values              map[string]any
+		expectedConstraints []string
+	}{
+		{
+			name: "valid",
+			values: map[string]any{
+				"age": float64(30), "email": "a@b.c", "status": "active", "ids": []int{1, 2}, "price": "0.50",
+			},
+		},
+		{
+			name: "every violation is reported once",
+			values: map[string]any{
+				"age": 12, "email": "not-an-email-address-at-all", "status": "gone", "ids": []int{0, 1, 2, 3}, "price": "-1",
+			},
+			expectedConstraints: []string{"min", "max_length", "pattern", "enum", "max_items", "min", "min"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsed.Bind(tt.values)
+			if len(tt.expectedConstraints) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, sqlparams.ErrConstraintViolation) {
+				t.Fatalf("expected %v, got %v", sqlparams.ErrConstraintViolation, err)
+			}
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("expected combined violations, got %v", err)
+			}
+			errs := joined.Unwrap()
+			if len(errs) != len(tt.expectedConstraints) {
+				t.Fatalf("expected %d violations, got %d: %v", len(tt.expectedConstraints), len(errs), err)
+			}
+			for i, e := range errs {
+				constraint, _ := sqlparams.ErrValue[string](e, "constraint")
+				if constraint != tt.expectedConstraints[i] {
+					t.Errorf("violation %d: expected %q, got %q", i, tt.expectedConstraints[i], constraint)
+				}
+				if _, ok := sqlparams.ErrValue[sqlparams.Selector](e, "name"); !ok {
+					t.Errorf("violation %d has no name: %v", i, e)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSQL_InvalidConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints map[sqlparams.Selector]sqlparams.Constraint
+	}{
+		{name: "unknown parameter", constraints: map[sqlparams.Selector]sqlparams.Constraint{"nope": {MaxLength: 1}}},
+		{name: "bad pattern", constraints: map[sqlparams.Selector]sqlparams.Constraint{"a": {Pattern: "("}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sqlparams.PostgresDialect.ParseSQL("SELECT :a", sqlparams.ParseSQLArgs{Constraints: tt.constraints})
+			if !errors.Is(err, sqlparams.ErrInvalidConstraint) {
+				t.Errorf("expected %v, got %v", sqlparams.ErrInvalidConstraint, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+var (
+	testTenantID = sqlparams.NewContextKey[int64]("test_tenant_id")
+	testUserID   = sqlparams.NewContextKey[string]("test_user_id")
+)
+
+func init() {
+	for _, key := range []sqlparams.ContextParam{testTenantID, testUserID} {
+		err := sqlparams.RegisterContextKey(key)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestParsedSQL_BindContext(t *testing.T) {
+	ctx := testTenantID.WithValue(context.Background(), 42)
+	ctx = testUserID.WithValue(ctx, "u-1")
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "context and values",
+			ctx:          ctx,
+			sql:          "SELECT * FROM t WHERE tenant = :ctx.test_tenant_id AND owner = :ctx.test_user_id AND id = :id",
+			values:       map[string]any{"id": 1},
+			expectedArgs: []any{int64(42), "u-1", 1},
+		},
+		{
+			name:          "values cannot supply context parameters",
+			ctx:           context.Background(),
+			sql:           "SELECT * FROM t WHERE tenant = :ctx.test_tenant_id",
+			values:        map[string]any{"ctx.test_tenant_id": 7, "ctx": map[string]any{"test_tenant_id": 7}},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+		{
+			name:          "unregistered key",
+			ctx:           ctx,
+			sql:           "SELECT :ctx.nope",
+			expectedError: sqlparams.ErrUnknownContextKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.BindContext(tt.ctx, tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestBindRequest_Context(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL("SELECT * FROM t WHERE tenant = :ctx.test_tenant_id")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(testTenantID.WithValue(r.Context(), 5))
+	bound, err := sqlparams.BindRequest(r, parsed)
+	if err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+	if !reflect.DeepEqual(bound.Args, []any{int64(5)}) {
+		t.Errorf("expected [5], got %#v", bound.Args)
+	}
+}
+
+func TestRegisterContextKey(t *testing.T) {
+	keys := []sqlparams.ContextParam{
+		sqlparams.NewContextKey[int64]("test_tenant_id"),
+		sqlparams.NewContextKey[int64]("not-valid"),
+		nil,
+	}
+	for _, key := range keys {
+		err := sqlparams.RegisterContextKey(key)
+		if !errors.Is(err, sqlparams.ErrInvalidContextKey) {
+			t.Errorf("%v: expected %v, got %v", key, sqlparams.ErrInvalidContextKey, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindDataTypes(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "coerces JSON numbers",
+			sql:          "SELECT * FROM t WHERE id = :id:integer AND score > :min:real LIMIT :limit:int=50",
+			values:       map[string]any{"id": float64(42), "min": 3},
+			expectedSQL:  "SELECT * FROM t WHERE id = $1 AND score > $2 LIMIT $3",
+			expectedArgs: []any{int64(42), float64(3), int64(50)},
+		},
+		{
+			name:         "json marshals maps",
+			sql:          "UPDATE t SET meta = :meta:json WHERE name = :name:string",
+			values:       map[string]any{"meta": map[string]any{"a": 1}, "name": "x"},
+			expectedSQL:  "UPDATE t SET meta = $1 WHERE name = $2",
+			expectedArgs: []any{`{"a":1}`, "x"},
+		},
+		{
+			name:         "NULL-able type with NULL default is optional",
+			sql:          "SELECT * FROM t WHERE (:status:string?=null IS NULL OR status = :status)",
+			values:       map[string]any{},
+			expectedSQL:  "SELECT * FROM t WHERE ($1 IS NULL OR status = $1)",
+			expectedArgs: []any{nil},
+		},
+		{
+			name:         "NULL-able type accepts NULL",
+			sql:          "SELECT * FROM t WHERE (:status:string? IS NULL OR status = :status)",
+			values:       map[string]any{"status": nil},
+			expectedSQL:  "SELECT * FROM t WHERE ($1 IS NULL OR status = $1)",
+			expectedArgs: []any{nil},
+		},
+		{
+			name:          "NULL-able type is still required",
+			sql:           "SELECT * FROM t WHERE (:status:string? IS NULL OR status = :status)",
+			values:        map[string]any{},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+		{
+			name:         "casts and unknown words are not annotations",
+			sql:          "SELECT :id::integer, :lo:hi",
+			values:       map[string]any{"id": "7", "lo": 1, "hi": 2},
+			expectedSQL:  "SELECT $1::integer, $2$3",
+			expectedArgs: []any{"7", 1, 2},
+		},
+		{
+			name:          "fractional integer",
+			sql:           "SELECT * FROM t WHERE id = :id:integer",
+			values:        map[string]any{"id": 4.5},
+			expectedError: sqlparams.ErrInvalidDataType,
+		},
+		{
+			name:          "NULL for non-NULL-able type",
+			sql:           "SELECT * FROM t WHERE name = :name:string",
+			values:        map[string]any{"name": nil},
+			expectedError: sqlparams.ErrInvalidDataType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_DataTypes(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"SELECT * FROM t WHERE id = :id:INT OR parent = :id AND note = :note:string?",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := sqlparams.Parameters{
+		{Name: "id", Index: 1, DataType: sqlparams.IntegerDBDataType},
+		{Name: "note", Index: 2, DataType: sqlparams.StringDBDataTypeOrNULL},
+	}
+	if !reflect.DeepEqual(parsed.Parameters(), expected) {
+		t.Errorf("Parameters mismatch:\nexpected: %#v\nactual:   %#v", expected, parsed.Parameters())
+	}
+
+	_, err = sqlparams.PostgresDialect.ParseSQL("SELECT :id:integer, :id:string")
+	if !errors.Is(err, sqlparams.ErrConflictingDataType) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrConflictingDataType, err)
+	}
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParseDBDataType(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      sqlparams.DBDataType
+		expectedError error
+	}{
+		{input: "", expected: sqlparams.DefaultDBDataType},
+		{input: "INT?", expected: sqlparams.IntegerDBDataTypeOrNULL},
+		{input: "bool", expected: sqlparams.BooleanDBDataType},
+		{input: "timestamptz?", expected: sqlparams.TimestampTZDBDataTypeOrNULL},
+		{input: "uuid[]", expected: "uuid[]"},
+		{input: "int?[]?", expected: "integer?[]?"},
+		{input: "decimal", expected: sqlparams.DecimalDBDataType},
+		{input: "integer[][]", expectedError: sqlparams.ErrInvalidDataType},
+		{input: "money", expectedError: sqlparams.ErrInvalidDataType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := sqlparams.ParseDBDataType(tt.input)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+
+	_, err := sqlparams.ParseColumnTypes([]string{"uuid", "date?", "bogus"})
+	if !errors.Is(err, sqlparams.ErrInvalidResultsColumnDataType) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrInvalidResultsColumnDataType, err)
+	}
+}
+
+func TestCoerce_ExtendedTypes(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	uuid := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	tests := []struct {
+		name          string
+		value         any
+		dataType      sqlparams.DBDataType
+		expected      any
+		expectedError error
+	}{
+		{name: "boolean from string", value: "t", dataType: sqlparams.BooleanDBDataType, expected: true},
+		{name: "boolean from float64", value: float64(0), dataType: sqlparams.BooleanDBDataType, expected: false},
+		{name: "boolean from 2", value: 2, dataType: sqlparams.BooleanDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "uuid canonicalized", value: "{123E4567-E89B-12D3-A456-426614174000}", dataType: sqlparams.UUIDDBDataType, expected: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "uuid without hyphens", value: "123e4567e89b12d3a456426614174000", dataType: sqlparams.UUIDDBDataType, expected: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "uuid from array", value: uuid, dataType: sqlparams.UUIDDBDataType, expected: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "uuid malformed", value: "123e4567-e89b", dataType: sqlparams.UUIDDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "date from string", value: "2024-02-29", dataType: sqlparams.DateDBDataType, expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "date from time", value: time.Date(2024, 2, 29, 23, 0, 0, 0, est), dataType: sqlparams.DateDBDataType, expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid date", value: "2023-02-29", dataType: sqlparams.DateDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "time from short string", value: "09:30", dataType: sqlparams.TimeDBDataType, expected: "09:30:00"},
+		{name: "timestamp keeps wall clock", value: "2024-01-02T03:04:05-05:00", dataType: sqlparams.TimestampDBDataType, expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "timestamptz keeps zone", value: "2024-01-02 03:04:05", dataType: sqlparams.TimestampTZDBDataType, expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "decimal keeps digits", value: json.Number("12345678901234567890.123456789"), dataType: sqlparams.DecimalDBDataType, expected: "12345678901234567890.123456789"},
+		{name: "decimal from int", value: 42, dataType: sqlparams.DecimalDBDataType, expected: "42"},
+		{name: "decimal from word", value: "lots", dataType: sqlparams.DecimalDBDataType, expectedError: sqlparams.ErrInvalidDataType},
+		{name: "bytes from base64", value: "aGk=", dataType: sqlparams.BytesDBDataType, expected: []byte("hi")},
+		{name: "bytes from raw url base64", value: "-_8", dataType: sqlparams.BytesDBDataType, expected: []byte{0xfb, 0xff}},
+		{name: "integer array from JSON", value: []any{float64(1), json.Number("2")}, dataType: "integer[]", expected: []int64{1, 2}},
+		{name: "NULL-able elements", value: []any{"a", nil}, dataType: "string?[]", expected: []any{"a", nil}},
+		{name: "NULL element rejected", value: []any{"a", nil}, dataType: "string[]", expectedError: sqlparams.ErrInvalidDataType},
+		{name: "NULL-able array", value: nil, dataType: "uuid[]?", expected: nil},
+		{name: "array from scalar", value: "a", dataType: "string[]", expectedError: sqlparams.ErrInvalidDataType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := sqlparams.Coerce(tt.value, tt.dataType)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsedSQL_BindArrayAnnotation(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL("SELECT * FROM t WHERE id = ANY(:ids:uuid[]) AND day = :day:date?=null")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	bound, err := parsed.Bind(map[string]any{"ids": []string{"123E4567E89B12D3A456426614174000"}})
+	if err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+	expected := []any{[]string{"123e4567-e89b-12d3-a456-426614174000"}, nil}
+	if !reflect.DeepEqual(bound.Args, expected) {
+		t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", expected, bound.Args)
+	}
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestDB_Named(t *testing.T) {
+	type user struct {
+		ID    int64  `db:"id"`
+		Email string `json:"email"`
+	}
+	sqlDB, fake := newFakeDB(t)
+	fake.results["SELECT name FROM users WHERE id = $1"] = fakeResult{
+		columns: []string{"name"},
+		rows:    [][]any{{"ann"}},
+	}
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+	ctx := testTenantID.WithValue(context.Background(), 3)
+
+	tests := []struct {
+		name         string
+		run          func() error
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{
+			name: "exec with map",
+			run: func() error {
+				_, err := db.ExecNamed(ctx, "UPDATE users SET email = :email WHERE id = :id AND tenant = :ctx.test_tenant_id", map[string]any{"id": 7, "email": "a@b.c"})
+				return err
+			},
+			expectedSQL:  "UPDATE users SET email = $1 WHERE id = $2 AND tenant = $3",
+			expectedArgs: []any{"a@b.c", int64(7), int64(3)},
+		},
+		{
+			name: "query with struct",
+			run: func() error {
+				rows, err := db.QueryNamed(ctx, "SELECT * FROM users WHERE id = :id OR email = :email", &user{ID: 7, Email: "a@b.c"})
+				if err == nil {
+					err = rows.Close()
+				}
+				return err
+			},
+			expectedSQL:  "SELECT * FROM users WHERE id = $1 OR email = $2",
+			expectedArgs: []any{int64(7), "a@b.c"},
+		},
+		{
+			name: "query row with resolver",
+			run: func() error {
+				var name string
+				err := db.QueryRowNamed(ctx, "SELECT name FROM users WHERE id = :path.id", sqlparams.NamespacedResolver{
+					sqlparams.PathNamespace: sqlparams.MapResolver{"id": 7},
+				}).Scan(&name)
+				if err == nil && name != "ann" {
+					err = errors.New("unexpected name " + name)
+				}
+				return err
+			},
+			expectedSQL:  "SELECT name FROM users WHERE id = $1",
+			expectedArgs: []any{int64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			call := fake.lastCall()
+			if call.query != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, call.query)
+			}
+			if !reflect.DeepEqual(fake.lastArgs(), tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, fake.lastArgs())
+			}
+		})
+	}
+}
+
+func TestDB_NamedErrors(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	db := sqlparams.NewDB(sqlDB, sqlparams.MySQLDialect)
+	ctx := context.Background()
+
+	_, err := db.ExecNamed(ctx, "UPDATE t SET a = :a", nil)
+	if !errors.Is(err, sqlparams.ErrMissingParameter) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrMissingParameter, err)
+	}
+	_, err = db.QueryNamed(ctx, "SELECT :user.0.name", nil)
+	if !errors.Is(err, sqlparams.ErrInvalidPlaceholderName) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrInvalidPlaceholderName, err)
+	}
+	row := db.QueryRowNamed(ctx, "SELECT :ctx.test_tenant_id", map[string]any{"ctx": map[string]any{"test_tenant_id": 1}})
+	if !errors.Is(row.Err(), sqlparams.ErrMissingParameter) || !errors.Is(row.Scan(), sqlparams.ErrMissingParameter) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrMissingParameter, row.Err())
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no queries to run, got %d", len(fake.calls))
+	}
+}
+
+func TestTxAndConn_Named(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	db := sqlparams.NewDB(sqlDB, sqlparams.SQLServerNamedDialect)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = tx.ExecNamed(ctx, "DELETE FROM t WHERE id = :id", map[string]any{"id": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []any{sql.Named("id", int64(1))}
+	if !reflect.DeepEqual(fake.lastArgs(), expected) {
+		t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", expected, fake.lastArgs())
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	tx, err = conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = tx.ExecNamed(ctx, "DELETE FROM t WHERE id = :id", map[string]any{"id": 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tx.Rollback()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.lastCall().query != "DELETE FROM t WHERE id = @id" {
+		t.Errorf("unexpected SQL %q", fake.lastCall().query)
+	}
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestDialect_Bind(t *testing.T) {
+	tests := []struct {
+		name          string
+		dialect       sqlparams.Dialect
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "postgres",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE a = :a AND b = :b AND c = :a",
+			values:       map[string]any{"a": 1, "b": 2},
+			expectedSQL:  "SELECT * FROM t WHERE a = $1 AND b = $2 AND c = $1",
+			expectedArgs: []any{1, 2},
+		},
+		{
+			name:         "mysql",
+			dialect:      sqlparams.MySQLDialect,
+			sql:          "SELECT * FROM t WHERE a = :a AND b = :b AND c = :a",
+			values:       map[string]any{"a": 1, "b": 2},
+			expectedSQL:  "SELECT * FROM t WHERE a = ? AND b = ? AND c = ?",
+			expectedArgs: []any{1, 2, 1},
+		},
+		{
+			name:         "sql server",
+			dialect:      sqlparams.SQLServerDialect,
+			sql:          "SELECT * FROM t WHERE a = :a AND b = :b",
+			values:       map[string]any{"a": 1, "b": 2},
+			expectedSQL:  "SELECT * FROM t WHERE a = @p1 AND b = @p2",
+			expectedArgs: []any{1, 2},
+		},
+		{
+			name:        "sql server named",
+			dialect:     sqlparams.SQLServerNamedDialect,
+			sql:         "SELECT * FROM products WHERE id = :items[0].id AND owner = :user.id OR id = :items[0].id",
+			values:      map[string]any{"items[0].id": 7, "user.id": 42},
+			expectedSQL: "SELECT * FROM products WHERE id = @items_0_id AND owner = @user_id OR id = @items_0_id",
+			expectedArgs: []any{
+				sql.Named("items_0_id", 7),
+				sql.Named("user_id", 42),
+			},
+		},
+		{
+			name:        "oracle named",
+			dialect:     sqlparams.OracleNamedDialect,
+			sql:         "SELECT * FROM users WHERE email = :email",
+			values:      map[string]any{"email": "a@example.com"},
+			expectedSQL: "SELECT * FROM users WHERE email = :email",
+			expectedArgs: []any{
+				sql.Named("email", "a@example.com"),
+			},
+		},
+		{
+			name:          "native name collision",
+			dialect:       sqlparams.SQLiteNamedDialect,
+			sql:           "SELECT * FROM users WHERE id = :user.id OR id = :user_id",
+			expectedError: sqlparams.ErrNativeNameCollision,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.dialect.ParseSQL(tt.sql)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			if parsed.ParamStyle() != tt.dialect.ParamStyle {
+				t.Errorf("ParamStyle mismatch: expected %q, got %q", tt.dialect.ParamStyle, parsed.ParamStyle())
+			}
+			bound, err := parsed.Bind(tt.values)
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_InfersNamedParamStyle(t *testing.T) {
+	parsed, err := sqlparams.ParseSQLWithTokenFunc("SELECT * FROM users WHERE email = :email",
+		func(t sqlparams.QueryToken) string {
+			return "@" + string(t.Name.NativeName())
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.ParamStyle() != sqlparams.NamedParamStyle {
+		t.Errorf("ParamStyle mismatch: expected %q, got %q", sqlparams.NamedParamStyle, parsed.ParamStyle())
+	}
+}
@@ -0,0 +1,207 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestWrapConnector(t *testing.T) {
+	fake := &fakeDriver{results: map[string]fakeResult{
+		"SELECT name FROM users WHERE id = $1": {columns: []string{"name"}, rows: [][]any{{"ann"}}},
+	}}
+	db := sql.OpenDB(sqlparams.WrapConnector(fake, sqlparams.PostgresDialect))
+	t.Cleanup(func() { _ = db.Close() })
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		query         string
+		args          []any
+		expectedSQL   string
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "named arguments",
+			query:        "UPDATE t SET a = :a WHERE id IN (:ids...) AND b = :a",
+			args:         []any{sql.Named("ids", []int{1, 2}), sql.Named("a", "x")},
+			expectedSQL:  "UPDATE t SET a = $1 WHERE id IN ($2, $3) AND b = $1",
+			expectedArgs: []any{"x", int64(1), int64(2)},
+		},
+		{
+			name:         "nested values",
+			query:        "UPDATE t SET a = :user.name:string",
+			args:         []any{sql.Named("user", map[string]any{"name": "ann"})},
+			expectedSQL:  "UPDATE t SET a = $1",
+			expectedArgs: []any{"ann"},
+		},
+		{
+			name:         "positional arguments pass through",
+			query:        "DELETE FROM t WHERE id = $1",
+			args:         []any{5},
+			expectedSQL:  "DELETE FROM t WHERE id = $1",
+			expectedArgs: []any{int64(5)},
+		},
+		{
+			name:          "mixed arguments",
+			query:         "DELETE FROM t WHERE id = :id",
+			args:          []any{sql.Named("id", 1), 2},
+			expectedError: sqlparams.ErrUnnamedArgument,
+		},
+		{
+			name:         "conditional block names are used",
+			query:        "DELETE FROM t WHERE id = :id /*{if :b}*/ AND b = 1 /*{end}*/",
+			args:         []any{sql.Named("id", 1), sql.Named("b", true)},
+			expectedSQL:  "DELETE FROM t WHERE id = $1  AND b = 1 ",
+			expectedArgs: []any{int64(1)},
+		},
+		{
+			name:          "unused named argument",
+			query:         "DELETE FROM t WHERE id = :id",
+			args:          []any{sql.Named("id", 1), sql.Named("extra", 2)},
+			expectedError: sqlparams.ErrUnusedArgument,
+		},
+		{
+			name:          "named arguments without placeholders",
+			query:         "DELETE FROM t WHERE id = $1",
+			args:          []any{sql.Named("id", 1)},
+			expectedError: sqlparams.ErrUnusedArgument,
+		},
+		{
+			name:          "missing argument",
+			query:         "DELETE FROM t WHERE id = :id AND b = :b",
+			args:          []any{sql.Named("id", 1)},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.ExecContext(ctx, tt.query, tt.args...)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fake.lastCall().query != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, fake.lastCall().query)
+			}
+			if !reflect.DeepEqual(fake.lastArgs(), tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, fake.lastArgs())
+			}
+		})
+	}
+
+	t.Run("query row", func(t *testing.T) {
+		var name string
+		err := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = :id", sql.Named("id", 7)).Scan(&name)
+		if err != nil || name != "ann" {
+			t.Fatalf("unexpected result %q, %v", name, err)
+		}
+	})
+
+	t.Run("prepared statement", func(t *testing.T) {
+		stmt, err := db.PrepareContext(ctx, "SELECT name FROM users WHERE id = :id")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer func() { _ = stmt.Close() }()
+		for _, id := range []int{7, 8} {
+			var name string
+			err = stmt.QueryRowContext(ctx, sql.Named("id", id)).Scan(&name)
+			if err != nil || name != "ann" {
+				t.Fatalf("unexpected result %q, %v", name, err)
+			}
+			if !reflect.DeepEqual(fake.lastArgs(), []any{int64(id)}) {
+				t.Errorf("unexpected args %#v", fake.lastArgs())
+			}
+		}
+	})
+
+	t.Run("prepared spread", func(t *testing.T) {
+		stmt, err := db.PrepareContext(ctx, "DELETE FROM t WHERE id IN (:ids...)")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer func() { _ = stmt.Close() }()
+		_, err = stmt.ExecContext(ctx, sql.Named("ids", []int64{3, 4, 5}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fake.lastCall().query != "DELETE FROM t WHERE id IN ($1, $2, $3)" {
+			t.Errorf("unexpected SQL %q", fake.lastCall().query)
+		}
+	})
+}
+
+func TestWrapConnector_Ping(t *testing.T) {
+	fake := &fakeDriver{results: map[string]fakeResult{}}
+	db := sql.OpenDB(sqlparams.WrapConnector(fake, sqlparams.PostgresDialect))
+	t.Cleanup(func() { _ = db.Close() })
+
+	err := db.PingContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.pings != 1 {
+		t.Errorf("expected the wrapped connection to be pinged once, got %d", fake.pings)
+	}
+
+	fake.pingErr = errors.New("connection refused")
+	err = db.PingContext(context.Background())
+	if !errors.Is(err, fake.pingErr) {
+		t.Errorf("expected %v, got %v", fake.pingErr, err)
+	}
+}
+
+func TestWrapConnector_NamedDialect(t *testing.T) {
+	fake := &fakeDriver{results: map[string]fakeResult{}}
+	db := sql.OpenDB(sqlparams.WrapConnector(fake, sqlparams.SQLServerNamedDialect))
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err := db.Exec("UPDATE t SET a = :a WHERE id = :user.id", sql.Named("a", "x"), sql.Named("user", struct{ ID int }{9}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.lastCall().query != "UPDATE t SET a = @a WHERE id = @user_id" {
+		t.Errorf("unexpected SQL %q", fake.lastCall().query)
+	}
+	expected := []any{sql.Named("a", "x"), sql.Named("user_id", int64(9))}
+	if !reflect.DeepEqual(fake.lastArgs(), expected) {
+		t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", expected, fake.lastArgs())
+	}
+}
+
+func TestRegisterDriver(t *testing.T) {
+	fake := &fakeDriver{results: map[string]fakeResult{}}
+	err := sqlparams.RegisterDriver("sqlparams-test-fake", fake, sqlparams.MySQLDialect)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db, err := sql.Open("sqlparams-test-fake", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec("DELETE FROM t WHERE a = :a OR b = :a", sql.Named("a", 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.lastCall().query != "DELETE FROM t WHERE a = ? OR b = ?" || len(fake.lastArgs()) != 2 {
+		t.Errorf("unexpected call %q %v", fake.lastCall().query, fake.lastArgs())
+	}
+
+	err = sqlparams.RegisterDriver("sqlparams-test-fake", fake, sqlparams.MySQLDialect)
+	if !errors.Is(err, sqlparams.ErrDuplicateDriver) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrDuplicateDriver, err)
+	}
+}
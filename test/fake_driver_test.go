@@ -0,0 +1,224 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is an in-memory database/sql driver that records the queries
+// it is sent and answers them with canned results.
+type fakeDriver struct {
+	mu       sync.Mutex
+	calls    []fakeCall
+	results  map[string]fakeResult
+	prepared int
+	closed   int
+	badConns int // number of upcoming calls that fail with driver.ErrBadConn
+	rowsRead int
+	pings    int
+	pingErr  error
+}
+
+type fakeCall struct {
+	query string
+	args  []driver.NamedValue
+}
+
+type fakeResult struct {
+	columns []string
+	types   []string
+	rows    [][]any
+}
+
+// newFakeDB returns a *sql.DB backed by a new fakeDriver.
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDriver) {
+	t.Helper()
+	d := &fakeDriver{results: make(map[string]fakeResult)}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) record(query string, args []driver.NamedValue) (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.badConns > 0 {
+		d.badConns--
+		return driver.ErrBadConn
+	}
+	d.calls = append(d.calls, fakeCall{query: query, args: args})
+	return nil
+}
+
+func (d *fakeDriver) lastCall() (call fakeCall) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.calls) > 0 {
+		call = d.calls[len(d.calls)-1]
+	}
+	return call
+}
+
+// lastArgs returns the values, or NamedArgs for named values, of the last
+// call's arguments.
+func (d *fakeDriver) lastArgs() (args []any) {
+	for _, nv := range d.lastCall().args {
+		if nv.Name != "" {
+			args = append(args, sql.Named(nv.Name, nv.Value))
+			continue
+		}
+		args = append(args, nv.Value)
+	}
+	return args
+}
+
+func (d *fakeDriver) rows(query string) *fakeRows {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &fakeRows{d: d, result: d.results[query]}
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.prepared++
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.pings++
+	return c.d.pingErr
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+// CheckNamedValue converts values as database/sql does by default, but
+// also accepts values it cannot convert, such as []string.
+func (c *fakeConn) CheckNamedValue(nv *driver.NamedValue) error {
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err == nil {
+		nv.Value = v
+	}
+	return nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	err := c.d.record(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return c.d.rows(query), nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	err := c.d.record(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	s.c.d.closed++
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+type fakeRows struct {
+	d      *fakeDriver
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.result.columns
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(i int) string {
+	if i < len(r.result.types) {
+		return r.result.types[i]
+	}
+	return ""
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	r.d.mu.Lock()
+	r.d.rowsRead++
+	r.d.mu.Unlock()
+	for i, v := range r.result.rows[r.next] {
+		dest[i] = v
+	}
+	r.next++
+	return nil
+}
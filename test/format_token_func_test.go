@@ -0,0 +1,104 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParseSQL_FormatTokenFunc(t *testing.T) {
+	tests := []struct {
+		name            string
+		sql             sqlparams.SQLQuery
+		formatTokenFunc sqlparams.FormatTokenFunc
+		expected        sqlparams.ParsedSQL
+		expectedError   error
+	}{
+		{
+			name: "sql server name-preserving placeholders",
+			sql:  "SELECT * FROM users WHERE email = :email AND id = :id",
+			formatTokenFunc: func(t sqlparams.QueryToken) string {
+				return "@" + string(t.Name.NativeName())
+			},
+			expected: sqlparams.NewParsedSQL("SELECT * FROM users WHERE email = @email AND id = @id", sqlparams.NewParameters("email", "id")),
+		},
+		{
+			name: "oracle name-preserving placeholders with duplicates",
+			sql:  "SELECT * FROM orders WHERE created_at >= :since AND updated_at >= :since",
+			formatTokenFunc: func(t sqlparams.QueryToken) string {
+				return ":" + string(t.Name.NativeName())
+			},
+			expected: sqlparams.NewParsedSQL("SELECT * FROM orders WHERE created_at >= :since AND updated_at >= :since", sqlparams.NewParameters("since")),
+		},
+		{
+			name: "index and occurrence number",
+			sql:  "SELECT * FROM t WHERE a = :a AND b = :b AND c = :a",
+			formatTokenFunc: func(t sqlparams.QueryToken) string {
+				return fmt.Sprintf("$%d/%d", t.Index, t.Occurrence)
+			},
+			expected: sqlparams.NewParsedSQL("SELECT * FROM t WHERE a = $1/1 AND b = $2/1 AND c = $1/2", sqlparams.NewParameters("a", "b")),
+		},
+		{
+			name: "named placeholders must render native names",
+			sql:  "SELECT * FROM users WHERE id = :user.id",
+			formatTokenFunc: func(t sqlparams.QueryToken) string {
+				return "@" + string(t.Name)
+			},
+			expectedError: sqlparams.ErrNamedFormatterMismatch,
+		},
+		{
+			name:            "nil format token function",
+			sql:             "SELECT * FROM users WHERE id = :id",
+			formatTokenFunc: nil,
+			expectedError:   sqlparams.ErrFormatParamFuncRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sqlparams.ParseSQLWithTokenFunc(tt.sql, tt.formatTokenFunc)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.SQL != tt.expected.SQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expected.SQL, result.SQL)
+			}
+			if len(result.Parameters()) != len(tt.expected.Parameters()) {
+				t.Fatalf("Parameters length mismatch: expected %d, got %d", len(tt.expected.Parameters()), len(result.Parameters()))
+			}
+			for i, expectedParam := range tt.expected.Parameters() {
+				if result.Parameters()[i] != expectedParam {
+					t.Errorf("Param[%d] mismatch: expected %v, got %v", i, expectedParam, result.Parameters()[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSQL_FormatParamFuncCompatibility(t *testing.T) {
+	var parse func(sqlparams.SQLQuery, sqlparams.FormatParamFunc, ...sqlparams.ParseSQLArgs) (sqlparams.ParsedSQL, error)
+	parse = sqlparams.ParseSQL
+
+	result, err := parse("SELECT * FROM users WHERE id = :id", func(i int) string {
+		return fmt.Sprintf("$%d", i)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.SQL != "SELECT * FROM users WHERE id = $1" {
+		t.Errorf("unexpected SQL %q", result.SQL)
+	}
+
+	_, err = sqlparams.ParseSQL("SELECT * FROM users WHERE id = :id", nil)
+	if !errors.Is(err, sqlparams.ErrFormatParamFuncRequired) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrFormatParamFuncRequired, err)
+	}
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindOptional(t *testing.T) {
+	tests := []struct {
+		name          string
+		dialect       sqlparams.Dialect
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "defaults bind when missing",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE status = :status?='active' AND score > :min?=-1.5 LIMIT :limit?=50",
+			values:       map[string]any{},
+			expectedSQL:  "SELECT * FROM t WHERE status = $1 AND score > $2 LIMIT $3",
+			expectedArgs: []any{"active", -1.5, int64(50)},
+		},
+		{
+			name:         "supplied values win over defaults",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE deleted = :deleted?=false LIMIT :limit?=50",
+			values:       map[string]any{"limit": 10, "deleted": true},
+			expectedSQL:  "SELECT * FROM t WHERE deleted = $1 LIMIT $2",
+			expectedArgs: []any{true, 10},
+		},
+		{
+			name:         "optional binds NULL",
+			dialect:      sqlparams.MySQLDialect,
+			sql:          "SELECT * FROM t WHERE (:status? IS NULL OR status = :status)",
+			values:       map[string]any{},
+			expectedSQL:  "SELECT * FROM t WHERE (? IS NULL OR status = ?)",
+			expectedArgs: []any{nil, nil},
+		},
+		{
+			name:         "quoted default with escaped quote",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT :name?='O''Brien'",
+			expectedSQL:  "SELECT $1",
+			expectedArgs: []any{"O'Brien"},
+		},
+		{
+			name:         "comparison with a column is not a default",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE :since=created_at",
+			values:       map[string]any{"since": "2024"},
+			expectedSQL:  "SELECT * FROM t WHERE $1=created_at",
+			expectedArgs: []any{"2024"},
+		},
+		{
+			name:         "comparisons with literals are not defaults",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE :active=1 AND (:mode='x' OR a = :b) AND :on=true",
+			values:       map[string]any{"active": 1, "mode": "x", "b": 2, "on": true},
+			expectedSQL:  "SELECT * FROM t WHERE $1=1 AND ($2='x' OR a = $3) AND $4=true",
+			expectedArgs: []any{1, "x", 2, true},
+		},
+		{
+			name:         "comparison in CASE is not a default",
+			dialect:      sqlparams.MySQLDialect,
+			sql:          "SELECT CASE WHEN :flag=true THEN 1 END",
+			values:       map[string]any{"flag": false},
+			expectedSQL:  "SELECT CASE WHEN ?=true THEN 1 END",
+			expectedArgs: []any{false},
+		},
+		{
+			name:          "required parameter still fails",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE a = :a LIMIT :limit?=50",
+			values:        map[string]any{},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.dialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_ParameterDefaults(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"SELECT * FROM t WHERE a = :a AND s = :status? LIMIT :limit OFFSET :limit?=50",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := sqlparams.Parameters{
+		{Name: "a", Index: 1},
+		{Name: "status", Index: 2, Optional: true},
+		{Name: "limit", Index: 3, Optional: true, Default: int64(50)},
+	}
+	if !reflect.DeepEqual(parsed.Parameters(), expected) {
+		t.Errorf("Parameters mismatch:\nexpected: %#v\nactual:   %#v", expected, parsed.Parameters())
+	}
+
+	_, err = sqlparams.PostgresDialect.ParseSQL("SELECT :limit?=50, :limit?=100")
+	if !errors.Is(err, sqlparams.ErrConflictingDefault) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrConflictingDefault, err)
+	}
+}
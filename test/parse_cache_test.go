@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+const cachedTemplate = "SELECT * FROM orders WHERE account = :account AND status = :status AND total > :min"
+
+func TestParseCache(t *testing.T) {
+	cache := sqlparams.NewParseCache(2)
+	parse := func(d sqlparams.Dialect, sql sqlparams.SQLQuery) sqlparams.ParsedSQL {
+		t.Helper()
+		ps, err := cache.ParseSQL(d, sql)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return ps
+	}
+
+	pg := parse(sqlparams.PostgresDialect, "SELECT :a")
+	my := parse(sqlparams.MySQLDialect, "SELECT :a")
+	if pg.SQL != "SELECT $1" || my.SQL != "SELECT ?" {
+		t.Errorf("expected per-dialect entries, got %q and %q", pg.SQL, my.SQL)
+	}
+	parse(sqlparams.PostgresDialect, "SELECT :a") // hit; MySQL is now least recent
+	parse(sqlparams.PostgresDialect, "SELECT :b") // evicts MySQL
+	parse(sqlparams.MySQLDialect, "SELECT :a")    // miss
+
+	_, err := cache.ParseSQL(sqlparams.PostgresDialect, "SELECT :user.0.name")
+	if err == nil {
+		t.Errorf("expected parse error")
+	}
+
+	expected := sqlparams.ParseCacheStats{Hits: 1, Misses: 5, Evictions: 2, Len: 2, Size: 2}
+	if cache.Stats() != expected {
+		t.Errorf("Stats mismatch:\nexpected: %+v\nactual:   %+v", expected, cache.Stats())
+	}
+
+	cache.Clear()
+	if cache.Stats().Len != 0 {
+		t.Errorf("expected empty cache after Clear, got %+v", cache.Stats())
+	}
+}
+
+func TestParseCache_KeysOnDialectAndArgs(t *testing.T) {
+	cache := sqlparams.NewParseCache(16)
+	const sql = "SELECT * FROM t WHERE id = :id:uuid OR parent = :id"
+
+	tests := []struct {
+		name        string
+		dialect     sqlparams.Dialect
+		args        []sqlparams.ParseSQLArgs
+		expectedSQL sqlparams.SQLQuery
+	}{
+		{
+			name:        "plain",
+			dialect:     sqlparams.PostgresDialect,
+			expectedSQL: "SELECT * FROM t WHERE id = $1 OR parent = $1",
+		},
+		{
+			name:        "with casts",
+			dialect:     sqlparams.PostgresDialect.WithCasts(),
+			expectedSQL: "SELECT * FROM t WHERE id = $1::uuid OR parent = $1::uuid",
+		},
+		{
+			name:        "different args",
+			dialect:     sqlparams.PostgresDialect,
+			args:        []sqlparams.ParseSQLArgs{{DisableDedupe: true}},
+			expectedSQL: "SELECT * FROM t WHERE id = $1 OR parent = $2",
+		},
+		{
+			name: "same name, different formatter",
+			dialect: func() sqlparams.Dialect {
+				d := sqlparams.PostgresDialect
+				d.FormatTokenFunc = sqlparams.IndexFormatter("@p%d")
+				return d
+			}(),
+			expectedSQL: "SELECT * FROM t WHERE id = @p1 OR parent = @p1",
+		},
+	}
+
+	// Twice, so the second round is served from the cache
+	for range 2 {
+		for _, tt := range tests {
+			ps, err := cache.ParseSQL(tt.dialect, sql, tt.args...)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.name, err)
+			}
+			if ps.SQL != tt.expectedSQL {
+				t.Errorf("%s: SQL mismatch:\nexpected: %q\nactual:   %q", tt.name, tt.expectedSQL, ps.SQL)
+			}
+		}
+	}
+	if stats := cache.Stats(); stats.Misses != 4 || stats.Hits != 4 {
+		t.Errorf("expected 4 misses and 4 hits, got %+v", stats)
+	}
+}
+
+// TestParseCache_FreshConstraints parses with a new Constraints map on every
+// call, collecting garbage in between, so a cache that keyed on addresses it
+// does not keep alive would see them reused and return the parse compiled
+// for another map.
+func TestParseCache_FreshConstraints(t *testing.T) {
+	cache := sqlparams.NewParseCache(16)
+	statuses := []string{"open", "closed"}
+
+	for i := range 2000 {
+		constraints := map[sqlparams.Selector]sqlparams.Constraint{"status": {Enum: []any{statuses[i%2]}}}
+		ps, err := cache.ParseSQL(
+			sqlparams.PostgresDialect,
+			"SELECT * FROM t WHERE status = :status",
+			sqlparams.ParseSQLArgs{Constraints: constraints},
+		)
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		_, err = ps.Bind(map[string]any{"status": statuses[i%2]})
+		if err != nil {
+			t.Fatalf("call %d: unexpected bind error: %v", i, err)
+		}
+		runtime.GC()
+	}
+	if stats := cache.Stats(); stats.Hits != 0 {
+		t.Errorf("expected no hits across fresh maps, got %+v", stats)
+	}
+}
+
+func TestParseCache_Concurrent(t *testing.T) {
+	cache := sqlparams.NewParseCache(8)
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				sql := sqlparams.SQLQuery(fmt.Sprintf("SELECT :p%d", (i+j)%12))
+				ps, err := cache.ParseSQL(sqlparams.PostgresDialect, sql)
+				if err != nil || ps.SQL != "SELECT $1" {
+					t.Errorf("unexpected parse of %q: %q, %v", sql, ps.SQL, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 1600 || stats.Len > 8 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestDB_ParseCache(t *testing.T) {
+	sqlDB, _ := newFakeDB(t)
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+	values := map[string]any{"account": 1, "status": "open", "min": 10}
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 3 {
+		_, err = tx.ExecNamed(ctx, cachedTemplate, values)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	_ = tx.Rollback()
+	stats := db.ParseCache().Stats()
+	if stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("expected 1 miss and 2 hits, got %+v", stats)
+	}
+
+	if db.WithParseCache(nil).ParseCache() != nil || db.ParseCache() == nil {
+		t.Errorf("expected WithParseCache to return a copy")
+	}
+}
+
+// TestParseCache_Allocations verifies that a cache hit allocates nothing,
+// so binding a cached template costs only the bind itself.
+func TestParseCache_Allocations(t *testing.T) {
+	cache := sqlparams.NewParseCache(8)
+	ps, err := cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	values := map[string]any{"account": 1, "status": "open", "min": 10}
+
+	hit := testing.AllocsPerRun(100, func() {
+		_, _ = cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+	})
+	if hit != 0 {
+		t.Errorf("expected a cache hit to allocate nothing, got %v allocations", hit)
+	}
+
+	bind := testing.AllocsPerRun(100, func() {
+		_, _ = ps.Bind(values)
+	})
+	cached := testing.AllocsPerRun(100, func() {
+		ps, _ := cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+		_, _ = ps.Bind(values)
+	})
+	if cached != bind {
+		t.Errorf("expected cached parse and bind to allocate as bind alone (%v), got %v", bind, cached)
+	}
+}
+
+func BenchmarkParseSQL(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = sqlparams.PostgresDialect.ParseSQL(cachedTemplate)
+	}
+}
+
+func BenchmarkParseCache_Hit(b *testing.B) {
+	cache := sqlparams.NewParseCache(8)
+	_, _ = cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+	}
+}
+
+func BenchmarkParseAndBind(b *testing.B) {
+	values := map[string]any{"account": 1, "status": "open", "min": 10}
+	b.ReportAllocs()
+	for b.Loop() {
+		ps, _ := sqlparams.PostgresDialect.ParseSQL(cachedTemplate)
+		_, _ = ps.Bind(values)
+	}
+}
+
+func BenchmarkParseCache_HitAndBind(b *testing.B) {
+	cache := sqlparams.NewParseCache(8)
+	values := map[string]any{"account": 1, "status": "open", "min": 10}
+	_, _ = cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+	b.ReportAllocs()
+	for b.Loop() {
+		ps, _ := cache.ParseSQL(sqlparams.PostgresDialect, cachedTemplate)
+		_, _ = ps.Bind(values)
+	}
+}
+
+func BenchmarkBind(b *testing.B) {
+	ps, _ := sqlparams.PostgresDialect.ParseSQL(cachedTemplate)
+	values := map[string]any{"account": 1, "status": "open", "min": 10}
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = ps.Bind(values)
+	}
+}
@@ -0,0 +1,198 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+const secret = "hunter2-0123456789"
+
+func TestParseSQL_Sensitive(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"UPDATE users SET pw = :new_password, pin = :pin:string|sensitive|trim, name = :name WHERE pin = :pin",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := sqlparams.Parameters{
+		{Name: "new_password", Index: 1, Sensitive: true},
+		{Name: "pin", Index: 2, DataType: sqlparams.StringDBDataType, Transforms: "trim", Sensitive: true},
+		{Name: "name", Index: 3},
+	}
+	if !reflect.DeepEqual(parsed.Parameters(), expected) {
+		t.Errorf("Parameters mismatch:\nexpected: %#v\nactual:   %#v", expected, parsed.Parameters())
+	}
+
+	parsed, err = sqlparams.PostgresDialect.ParseSQL(
+		"SELECT :new_password, :class_name, :user.ssn",
+		sqlparams.ParseSQLArgs{SensitiveNames: regexp.MustCompile(`^class_`)},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sensitive []bool
+	for _, p := range parsed.Parameters() {
+		sensitive = append(sensitive, p.Sensitive)
+	}
+	if !reflect.DeepEqual(sensitive, []bool{false, true, false}) {
+		t.Errorf("expected only class_name sensitive, got %v", sensitive)
+	}
+
+	if !sqlparams.DefaultSensitiveNames.MatchString("user.ssn") || sqlparams.DefaultSensitiveNames.MatchString("classname") {
+		t.Errorf("DefaultSensitiveNames should match user.ssn but not classname")
+	}
+}
+
+// TestRedaction verifies that the value of a sensitive parameter never
+// appears in errors, formatted BoundSQL or logs.
+func TestRedaction(t *testing.T) {
+	err := sqlparams.RegisterTransform("test_leaky", func(value any) (any, error) {
+		return nil, fmt.Errorf("cannot transform %v", value)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failures := []struct {
+		name   string
+		sql    sqlparams.SQLQuery
+		args   sqlparams.ParseSQLArgs
+		values map[string]any
+	}{
+		{
+			name:   "constraint violation",
+			sql:    "UPDATE users SET password = :password",
+			args:   sqlparams.ParseSQLArgs{Constraints: map[sqlparams.Selector]sqlparams.Constraint{"password": {MaxLength: 4, Pattern: "^[a-z]+$"}}},
+			values: map[string]any{"password": secret},
+		},
+		{
+			name:   "spread constraint violation",
+			sql:    "SELECT * FROM t WHERE token IN (:tokens...)",
+			args:   sqlparams.ParseSQLArgs{Constraints: map[sqlparams.Selector]sqlparams.Constraint{"tokens": {Enum: []any{"a"}}}},
+			values: map[string]any{"tokens": []string{secret}},
+		},
+		{
+			name:   "transform failure",
+			sql:    "SELECT :pin:any|sensitive|test_leaky",
+			values: map[string]any{"pin": secret},
+		},
+		{
+			name:   "data type failure",
+			sql:    "SELECT :api_key:uuid, :secret_json:json",
+			values: map[string]any{"api_key": secret, "secret_json": map[string]any{secret: func() {}}},
+		},
+		{
+			name:   "row template field",
+			sql:    "INSERT INTO users (name, password) VALUES :users(:name, :password:integer)",
+			values: map[string]any{"users": []map[string]any{{"name": "a", "password": secret}}},
+		},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql, tt.args)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			_, err = parsed.Bind(tt.values)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			assertRedacted(t, fmt.Sprintf("%v %+v %#v", err, err, err))
+			var all []error
+			if joined, ok := err.(interface{ Unwrap() []error }); ok {
+				all = joined.Unwrap()
+			} else {
+				all = []error{err}
+			}
+			for _, e := range all {
+				value, ok := sqlparams.ErrValue[any](e, "value")
+				if ok && value != sqlparams.RedactedValue {
+					t.Errorf("expected redacted value metadata, got %v", value)
+				}
+			}
+		})
+	}
+
+	parsed, err := sqlparams.SQLServerDialect.ParseSQL(
+		"UPDATE users SET password = :password, name = :name WHERE id = :id",
+	)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	bound, err := parsed.Bind(map[string]any{"password": secret, "name": "ann", "id": 1})
+	if err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+	if !bound.IsSensitive(0) || bound.IsSensitive(1) {
+		t.Errorf("expected only the first argument sensitive")
+	}
+	formatted := fmt.Sprintf("%v %+v %#v %s %v", bound, bound, bound, bound.String(), bound.RedactedArgs())
+	assertRedacted(t, formatted)
+	if !strings.Contains(formatted, "ann") {
+		t.Errorf("expected non-sensitive values to be formatted: %s", formatted)
+	}
+
+	var logs bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logs, nil)).Info("query", "bound", bound)
+	assertRedacted(t, logs.String())
+
+	if fmt.Sprint(bound.Args[0]) == fmt.Sprint(sqlparams.RedactedValue) {
+		t.Errorf("expected Args to keep the raw value for the driver")
+	}
+}
+
+func TestDB_QueryHookRedacts(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	var events []sqlparams.QueryEvent
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect).WithQueryHook(
+		func(_ context.Context, event sqlparams.QueryEvent) {
+			events = append(events, event)
+		},
+	)
+	ctx := context.Background()
+
+	_, err := db.ExecNamed(ctx, "UPDATE users SET pw = :password WHERE id = :id", map[string]any{"password": secret, "id": 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = tx.QueryRowNamed(ctx, "SELECT id FROM users WHERE token = :token", map[string]any{"token": secret}).Scan(new(int64))
+	_ = tx.Rollback()
+	_, err = db.QueryNamed(ctx, "SELECT :api_key:uuid", map[string]any{"api_key": secret})
+	if err == nil {
+		t.Fatalf("expected a bind error")
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	expected := []any{sqlparams.RedactedValue, 7}
+	if !reflect.DeepEqual(events[0].Args, expected) || events[0].SQL != "UPDATE users SET pw = $1 WHERE id = $2" {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	if events[2].Err == nil || events[2].Template != "SELECT :api_key:uuid" {
+		t.Errorf("expected the bind error to be reported, got %+v", events[2])
+	}
+	assertRedacted(t, fmt.Sprintf("%v %+v %v", events, events, events[2].Err))
+	if !reflect.DeepEqual(fake.lastArgs(), []any{secret}) {
+		t.Errorf("expected the driver to receive the raw value, got %v", fake.lastArgs())
+	}
+}
+
+func assertRedacted(t *testing.T, s string) {
+	t.Helper()
+	if strings.Contains(s, secret) {
+		t.Errorf("sensitive value leaked: %s", s)
+	}
+}
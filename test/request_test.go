@@ -0,0 +1,159 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestBindRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           sqlparams.SQLQuery
+		target        string
+		contentType   string
+		body          string
+		args          sqlparams.RequestArgs
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "path, query and header",
+			sql:          "SELECT * FROM orders WHERE account = :path.accountId AND status = :query.status AND tag IN (:query.tag...) AND req = :header.x_request_id",
+			target:       "/accounts/7/orders?status=open&tag=a",
+			expectedArgs: []any{"7", "open", "a", "r-1"},
+		},
+		{
+			name:         "repeated query value",
+			sql:          "SELECT :query.tag",
+			target:       "/?tag=a&tag=b",
+			expectedArgs: []any{[]string{"a", "b"}},
+		},
+		{
+			name:         "JSON body",
+			sql:          "INSERT INTO t (id, name) VALUES (:body.items[0].id:integer, :body.name)",
+			contentType:  "application/json; charset=utf-8",
+			body:         `{"name": "x", "items": [{"id": 9007199254740993}]}`,
+			expectedArgs: []any{int64(9007199254740993), "x"},
+		},
+		{
+			name:         "form body",
+			sql:          "UPDATE t SET name = :body.name",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "name=Ann+Lee",
+			expectedArgs: []any{"Ann Lee"},
+		},
+		{
+			name:         "multipart body",
+			sql:          "UPDATE t SET name = :body.name",
+			contentType:  "multipart/form-data; boundary=XYZ",
+			body:         "--XYZ\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nAnn\r\n--XYZ--\r\n",
+			expectedArgs: []any{"Ann"},
+		},
+		{
+			name:          "body too large",
+			sql:           "UPDATE t SET name = :body.name",
+			contentType:   "application/json",
+			body:          `{"name": "abcdefghij"}`,
+			args:          sqlparams.RequestArgs{MaxBodyBytes: 10},
+			expectedError: sqlparams.ErrInvalidRequestBody,
+		},
+		{
+			name:          "malformed JSON",
+			sql:           "UPDATE t SET name = :body.name",
+			contentType:   "application/json",
+			body:          `{"name": `,
+			expectedError: sqlparams.ErrInvalidRequestBody,
+		},
+		{
+			name:          "content type not accepted",
+			sql:           "UPDATE t SET name = :body.name",
+			contentType:   "application/x-www-form-urlencoded",
+			body:          "name=x",
+			args:          sqlparams.RequestArgs{ContentTypes: []string{"application/json"}},
+			expectedError: sqlparams.ErrUnsupportedContentType,
+		},
+		{
+			name:         "body ignored when unused",
+			sql:          "SELECT :query.q",
+			target:       "/?q=1",
+			contentType:  "text/plain",
+			body:         "anything",
+			expectedArgs: []any{"1"},
+		},
+		{
+			name:          "other namespaces rejected",
+			sql:           "SELECT :env.HOME",
+			expectedError: sqlparams.ErrUnknownNamespace,
+		},
+		{
+			name:         "extra resolvers",
+			sql:          "SELECT :ctx.tenant",
+			args:         sqlparams.RequestArgs{Resolvers: sqlparams.NamespacedResolver{sqlparams.ContextNamespace: sqlparams.MapResolver{"tenant": 3}}},
+			expectedArgs: []any{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+			r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			r.Header.Set("X-Request-Id", "r-1")
+			r.SetPathValue("accountId", "7")
+
+			bound, err := sqlparams.BindRequest(r, parsed, tt.args)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestBindRequest_ServeMux(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"SELECT * FROM orders WHERE account = :path.id:integer AND total > :body.min",
+	)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	var bound sqlparams.BoundSQL
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /accounts/{id}/orders", func(w http.ResponseWriter, r *http.Request) {
+		bound, err = sqlparams.BindRequest(r, parsed)
+	})
+	r := httptest.NewRequest(http.MethodPost, "/accounts/42/orders", strings.NewReader(`{"min": 10.5}`))
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+	expected := []any{int64(42), json.Number("10.5")}
+	if !reflect.DeepEqual(bound.Args, expected) {
+		t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", expected, bound.Args)
+	}
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindFrom(t *testing.T) {
+	t.Setenv("SQLPARAMS_TEST_REGION", "eu")
+	t.Setenv("SQLPARAMS_TEST_SECRET", "hunter2")
+
+	resolver := sqlparams.NamespacedResolver{
+		sqlparams.PathNamespace: sqlparams.MapResolver{"accountId": 7},
+		sqlparams.BodyNamespace: sqlparams.MapResolver{
+			"items": []map[string]any{{"id": 10}, {"id": 11}},
+		},
+		sqlparams.EnvNamespace: sqlparams.EnvResolver{"SQLPARAMS_TEST_REGION"},
+	}
+
+	tests := []struct {
+		name          string
+		sql           sqlparams.SQLQuery
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "routes on root segment",
+			sql:          "SELECT * FROM t WHERE account = :path.accountId AND item = :body.items[1].id AND region = :env.SQLPARAMS_TEST_REGION",
+			expectedSQL:  "SELECT * FROM t WHERE account = $1 AND item = $2 AND region = $3",
+			expectedArgs: []any{7, 11, "eu"},
+		},
+		{
+			name:          "unknown root",
+			sql:           "SELECT * FROM t WHERE id = :id",
+			expectedError: sqlparams.ErrUnknownNamespace,
+		},
+		{
+			name:          "root without resolver ignores default",
+			sql:           "SELECT * FROM t WHERE tenant = :ctx.tenant_id?=1",
+			expectedError: sqlparams.ErrUnknownNamespace,
+		},
+		{
+			name:          "unlisted environment variable",
+			sql:           "SELECT :env.SQLPARAMS_TEST_SECRET",
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+		{
+			name:          "missing path value",
+			sql:           "SELECT :path.other",
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.BindFrom(resolver)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestNamespacedResolver_Validate(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"SELECT * FROM t WHERE a = :path.id /*{if :secret.key}*/AND b = :query.q/*{end}*/",
+	)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	resolver := sqlparams.NamespacedResolver{
+		sqlparams.PathNamespace: sqlparams.MapResolver{},
+	}
+	err = resolver.Validate(parsed)
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected combined errors, got %v", err)
+	}
+	var namespaces []sqlparams.Namespace
+	for _, e := range joined.Unwrap() {
+		if !errors.Is(e, sqlparams.ErrUnknownNamespace) {
+			t.Errorf("expected %v, got %v", sqlparams.ErrUnknownNamespace, e)
+		}
+		ns, _ := sqlparams.ErrValue[sqlparams.Namespace](e, "namespace")
+		namespaces = append(namespaces, ns)
+	}
+	expected := []sqlparams.Namespace{"secret", "query"}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Errorf("expected namespaces %v, got %v", expected, namespaces)
+	}
+}
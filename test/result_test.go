@@ -0,0 +1,308 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestDB_QueryResult(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	fake.results["SELECT none"] = fakeResult{columns: []string{"v"}}
+	fake.results["SELECT ints"] = fakeResult{columns: []string{"n"}, rows: [][]any{{int64(1)}, {"2"}, {[]byte("3")}}}
+	fake.results["SELECT nullable"] = fakeResult{columns: []string{"n"}, rows: [][]any{{int64(1)}, {nil}}}
+	fake.results["SELECT real"] = fakeResult{columns: []string{"r"}, rows: [][]any{{1.5}}}
+	fake.results["SELECT name"] = fakeResult{columns: []string{"name"}, rows: [][]any{{[]byte("ann")}}}
+	fake.results["SELECT doc"] = fakeResult{columns: []string{"doc"}, rows: [][]any{{`{"b": 1, "a": [2]}`}, {nil}}}
+	fake.results["SELECT bad"] = fakeResult{columns: []string{"doc"}, rows: [][]any{{"{oops"}}}
+	fake.results["SELECT users"] = fakeResult{
+		columns: []string{"id", "name"},
+		rows:    [][]any{{int64(1), "ann"}, {int64(2), nil}},
+	}
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+
+	tests := []struct {
+		name           string
+		query          sqlparams.SQLQuery
+		shape          sqlparams.ResultShape
+		expectedResult any
+		expectedError  error
+	}{
+		{
+			name:           "string one",
+			query:          "SELECT name",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.StringRowType, Cardinality: sqlparams.OneRow},
+			expectedResult: "ann",
+		},
+		{
+			name:           "integer many",
+			query:          "SELECT ints",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.IntegerRowType, Cardinality: sqlparams.ManyRows},
+			expectedResult: []int64{1, 2, 3},
+		},
+		{
+			name:           "real one",
+			query:          "SELECT real",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.RealRowType, Cardinality: sqlparams.OneRow},
+			expectedResult: 1.5,
+		},
+		{
+			name:           "NULL-able integer many",
+			query:          "SELECT nullable",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.IntRowTypeOrNULL, Cardinality: sqlparams.ManyRows},
+			expectedResult: []any{int64(1), nil},
+		},
+		{
+			name:          "NULL for non-NULL-able integer",
+			query:         "SELECT nullable",
+			shape:         sqlparams.ResultShape{RowType: sqlparams.IntegerRowType, Cardinality: sqlparams.ManyRows},
+			expectedError: sqlparams.ErrInvalidResultsColumnDataType,
+		},
+		{
+			name:           "JSON verbatim",
+			query:          "SELECT doc",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.JSONRowTypeOrNULL, Cardinality: sqlparams.ManyRows},
+			expectedResult: []json.RawMessage{json.RawMessage(`{"b": 1, "a": [2]}`), nil},
+		},
+		{
+			name:          "invalid JSON",
+			query:         "SELECT bad",
+			shape:         sqlparams.ResultShape{RowType: sqlparams.JSONRowType, Cardinality: sqlparams.OneRow},
+			expectedError: sqlparams.ErrInvalidResultsColumnDataType,
+		},
+		{
+			name:  "columns many",
+			query: "SELECT users",
+			shape: sqlparams.ResultShape{RowType: sqlparams.ColumnsRowType, Cardinality: sqlparams.ManyRows},
+			expectedResult: []sqlparams.ColumnMap{
+				{{Name: "id", Value: int64(1)}, {Name: "name", Value: "ann"}},
+				{{Name: "id", Value: int64(2)}, {Name: "name", Value: nil}},
+			},
+		},
+		{
+			name:           "one? of none",
+			query:          "SELECT none",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.StringRowType, Cardinality: sqlparams.OneRowOrNone},
+			expectedResult: nil,
+		},
+		{
+			name:           "many? of none",
+			query:          "SELECT none",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.StringRowType, Cardinality: sqlparams.ManyRowsOrNone},
+			expectedResult: []string{},
+		},
+		{
+			name:          "cardinality enforced",
+			query:         "SELECT ints",
+			shape:         sqlparams.ResultShape{RowType: sqlparams.IntegerRowType, Cardinality: sqlparams.OneRow},
+			expectedError: sqlparams.ErrTooManyRows,
+		},
+		{
+			name:          "scalar of several columns",
+			query:         "SELECT users",
+			shape:         sqlparams.ResultShape{RowType: sqlparams.StringRowType},
+			expectedError: sqlparams.ErrInvalidRowType,
+		},
+		{
+			name:           "defaults",
+			query:          "SELECT users",
+			expectedResult: []sqlparams.ColumnMap{{{Name: "id", Value: int64(1)}, {Name: "name", Value: "ann"}}, {{Name: "id", Value: int64(2)}, {Name: "name", Value: nil}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := db.QueryResult(context.Background(), tt.shape, tt.query, nil)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expectedResult) {
+				t.Errorf("Result mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestDB_QueryResultSingleNULL(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	fake.results["SELECT null"] = fakeResult{columns: []string{"v"}, rows: [][]any{{nil}}}
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+
+	rowTypes := []sqlparams.DBRowType{
+		sqlparams.JSONRowTypeOrNULL,
+		sqlparams.IntegerRowTypeOrNULL,
+		sqlparams.StringRowTypeOrNULL,
+		sqlparams.AnyRowType,
+	}
+	for _, rt := range rowTypes {
+		for _, c := range []sqlparams.Cardinality{sqlparams.OneRow, sqlparams.OneRowOrNone} {
+			result, err := db.QueryResult(context.Background(), sqlparams.ResultShape{RowType: rt, Cardinality: c}, "SELECT null", nil)
+			if err != nil {
+				t.Fatalf("%s %s: unexpected error: %v", rt, c, err)
+			}
+			if result != nil {
+				t.Errorf("%s %s: expected an untyped nil, got %#v", rt, c, result)
+			}
+		}
+	}
+}
+
+func TestColumnMap(t *testing.T) {
+	cm := sqlparams.ColumnMap{{Name: "z", Value: 1}, {Name: "a", Value: "x"}, {Name: "m", Value: nil}}
+	data, err := json.Marshal([]sqlparams.ColumnMap{cm})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `[{"z":1,"a":"x","m":null}]` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+	if v, ok := cm.Get("a"); !ok || v != "x" {
+		t.Errorf("expected a to be x, got %v", v)
+	}
+	if _, ok := cm.Get("b"); ok {
+		t.Errorf("expected no column b")
+	}
+}
+
+func TestDB_QueryResultColumnTypes(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	fake.results["SELECT items"] = fakeResult{
+		columns: []string{"id", "meta", "note"},
+		rows: [][]any{
+			{[]byte("1"), `{"tags":["a"]}`, "x"},
+			{"2", nil, nil},
+		},
+	}
+	fake.results["SELECT text"] = fakeResult{columns: []string{"n"}, rows: [][]any{{"7"}, {"x"}}}
+	fake.results["SELECT doc"] = fakeResult{columns: []string{"doc"}, rows: [][]any{{[]byte(`{"a":1}`)}}}
+	fake.results["SELECT json null"] = fakeResult{columns: []string{"doc"}, rows: [][]any{{`{"a":1}`}, {" null"}}}
+	db := sqlparams.NewDB(sqlDB, sqlparams.SQLiteDialect)
+
+	columnTypes, err := sqlparams.ParseColumnTypes([]string{"int", "json?", "string?"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		query          sqlparams.SQLQuery
+		shape          sqlparams.ResultShape
+		expectedResult any
+		expectedError  error
+		expectedColumn string
+		expectedRow    int
+	}{
+		{
+			name:  "converts and decodes columns",
+			query: "SELECT items",
+			shape: sqlparams.ResultShape{ColumnTypes: columnTypes},
+			expectedResult: []sqlparams.ColumnMap{
+				{{Name: "id", Value: int64(1)}, {Name: "meta", Value: map[string]any{"tags": []any{"a"}}}, {Name: "note", Value: "x"}},
+				{{Name: "id", Value: int64(2)}, {Name: "meta", Value: nil}, {Name: "note", Value: nil}},
+			},
+		},
+		{
+			name:           "NULL in non-NULL-able column",
+			query:          "SELECT items",
+			shape:          sqlparams.ResultShape{ColumnTypes: []sqlparams.DBDataType{"integer", "json", "string?"}},
+			expectedError:  sqlparams.ErrInvalidResultsColumnDataType,
+			expectedColumn: "meta",
+			expectedRow:    2,
+		},
+		{
+			name:           "TEXT that is not an integer",
+			query:          "SELECT text",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.AnyRowType, ColumnTypes: []sqlparams.DBDataType{"integer"}},
+			expectedError:  sqlparams.ErrInvalidResultsColumnDataType,
+			expectedColumn: "n",
+			expectedRow:    2,
+		},
+		{
+			name:           "json row type stays verbatim",
+			query:          "SELECT doc",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.JSONRowType, Cardinality: sqlparams.OneRow, ColumnTypes: []sqlparams.DBDataType{"json"}},
+			expectedResult: json.RawMessage(`{"a":1}`),
+		},
+		{
+			name:           "string row type reads JSON as text",
+			query:          "SELECT doc",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.StringRowType, Cardinality: sqlparams.OneRow, ColumnTypes: []sqlparams.DBDataType{"json"}},
+			expectedResult: `{"a":1}`,
+		},
+		{
+			name:           "NULL-able string row type reads JSON as text",
+			query:          "SELECT json null",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.StringRowTypeOrNULL, ColumnTypes: []sqlparams.DBDataType{"json?"}},
+			expectedResult: []any{`{"a":1}`, " null"},
+		},
+		{
+			name:           "JSON null in NULL-able column",
+			query:          "SELECT json null",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.AnyRowType, ColumnTypes: []sqlparams.DBDataType{"json?"}},
+			expectedResult: []any{map[string]any{"a": float64(1)}, nil},
+		},
+		{
+			name:           "JSON null in non-NULL-able column",
+			query:          "SELECT json null",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.AnyRowType, ColumnTypes: []sqlparams.DBDataType{"json"}},
+			expectedError:  sqlparams.ErrInvalidResultsColumnDataType,
+			expectedColumn: "doc",
+			expectedRow:    2,
+		},
+		{
+			name:           "JSON null in non-NULL-able column read verbatim",
+			query:          "SELECT json null",
+			shape:          sqlparams.ResultShape{RowType: sqlparams.JSONRowType, ColumnTypes: []sqlparams.DBDataType{"json"}},
+			expectedError:  sqlparams.ErrInvalidResultsColumnDataType,
+			expectedColumn: "doc",
+			expectedRow:    2,
+		},
+		{
+			name:          "one type per column",
+			query:         "SELECT items",
+			shape:         sqlparams.ResultShape{ColumnTypes: []sqlparams.DBDataType{"integer"}},
+			expectedError: sqlparams.ErrInvalidResultsColumnDataType,
+		},
+		{
+			name:          "unknown column type",
+			query:         "SELECT doc",
+			shape:         sqlparams.ResultShape{ColumnTypes: []sqlparams.DBDataType{"bogus"}},
+			expectedError: sqlparams.ErrInvalidDataType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := db.QueryResult(context.Background(), tt.shape, tt.query, nil)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if tt.expectedColumn != "" {
+					column, _ := sqlparams.ErrValue[string](err, "column")
+					row, _ := sqlparams.ErrValue[int](err, "row")
+					if column != tt.expectedColumn || row != tt.expectedRow {
+						t.Errorf("expected column %q row %d, got %q row %d", tt.expectedColumn, tt.expectedRow, column, row)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expectedResult) {
+				t.Errorf("Result mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedResult, result)
+			}
+		})
+	}
+}
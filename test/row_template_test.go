@@ -0,0 +1,242 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+type cartItem struct {
+	ProductID int `db:"product_id"`
+	Quantity  int `json:"quantity"`
+	Note      string
+}
+
+type userRecord struct {
+	ID    int    `db:"id"`
+	Hash  string `json:"-"`
+	Token string `db:"-" json:"token"`
+}
+
+func TestParsedSQL_BindRowTemplate(t *testing.T) {
+	mapItems := []map[string]any{
+		{"product_id": 10, "quantity": 1},
+		{"product_id": 20, "quantity": 2},
+	}
+
+	tests := []struct {
+		name          string
+		dialect       sqlparams.Dialect
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "postgres shares top-level values across rows",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "INSERT INTO cart_items (cart_id, product_id, quantity) VALUES :items(:cart_id, :product_id, :quantity) RETURNING :cart_id",
+			values:       map[string]any{"cart_id": 5, "items": mapItems},
+			expectedSQL:  "INSERT INTO cart_items (cart_id, product_id, quantity) VALUES ($1, $2, $3), ($1, $4, $5) RETURNING $6",
+			expectedArgs: []any{5, 10, 1, 20, 2, 5},
+		},
+		{
+			name:         "mysql binds every field per row",
+			dialect:      sqlparams.MySQLDialect,
+			sql:          "INSERT INTO cart_items (cart_id, product_id, quantity) VALUES :items(:cart_id, :product_id, :quantity)",
+			values:       map[string]any{"cart_id": 5, "items": mapItems},
+			expectedSQL:  "INSERT INTO cart_items (cart_id, product_id, quantity) VALUES (?, ?, ?), (?, ?, ?)",
+			expectedArgs: []any{5, 10, 1, 5, 20, 2},
+		},
+		{
+			name:    "struct elements and nested parentheses",
+			dialect: sqlparams.PostgresDialect,
+			sql:     "INSERT INTO t (a, b, c) VALUES :items(:product_id, COALESCE(:quantity, 0), lower(:note))",
+			values: map[string]any{"items": []*cartItem{
+				{ProductID: 1, Quantity: 3, Note: "A"},
+				{ProductID: 2, Quantity: 4, Note: "B"},
+			}},
+			expectedSQL:  "INSERT INTO t (a, b, c) VALUES ($1, COALESCE($2, 0), lower($3)), ($4, COALESCE($5, 0), lower($6))",
+			expectedArgs: []any{1, 3, "A", 2, 4, "B"},
+		},
+		{
+			name:        "named dialect qualifies fields by element",
+			dialect:     sqlparams.SQLServerNamedDialect,
+			sql:         "INSERT INTO t (a, b) VALUES :items(:product_id, :quantity)",
+			values:      map[string]any{"items": mapItems[:1]},
+			expectedSQL: "INSERT INTO t (a, b) VALUES (@items_0_product_id, @items_0_quantity)",
+			expectedArgs: []any{
+				sql.Named("items_0_product_id", 10),
+				sql.Named("items_0_quantity", 1),
+			},
+		},
+		{
+			name:          "named dialect rejects field name collisions",
+			dialect:       sqlparams.SQLServerNamedDialect,
+			sql:           "INSERT INTO t (a, b) VALUES :items(:product_id, :items_0_product_id)",
+			values:        map[string]any{"items": mapItems[:1], "items_0_product_id": 3},
+			expectedError: sqlparams.ErrNativeNameCollision,
+		},
+		{
+			name:         "nested top-level values",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE owner = :user.id AND tag = :user.tags[1]",
+			values:       map[string]any{"user": map[string]any{"id": 7, "tags": []string{"a", "b"}}},
+			expectedSQL:  "SELECT * FROM t WHERE owner = $1 AND tag = $2",
+			expectedArgs: []any{7, "b"},
+		},
+		{
+			name:         "struct top-level values",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE id = :u.id",
+			values:       map[string]any{"u": userRecord{ID: 7, Hash: "h", Token: "x"}},
+			expectedSQL:  "SELECT * FROM t WHERE id = $1",
+			expectedArgs: []any{7},
+		},
+		{
+			name:          "json dash hides a field from its name",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE hash = :u.hash",
+			values:        map[string]any{"u": userRecord{ID: 7, Hash: "h"}},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+		{
+			name:          "db dash hides a field from its json tag",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE token = :u.token",
+			values:        map[string]any{"u": userRecord{ID: 7, Token: "x"}},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+		{
+			name:          "missing field in element",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "INSERT INTO t (a, b) VALUES :items(:product_id, :price)",
+			values:        map[string]any{"items": mapItems},
+			expectedError: sqlparams.ErrMissingParameter,
+		},
+		{
+			name:          "empty rows",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "INSERT INTO t (a) VALUES :items(:product_id)",
+			values:        map[string]any{"items": []any{}},
+			expectedError: sqlparams.ErrEmptySpreadValue,
+		},
+		{
+			name:          "rows not a slice",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "INSERT INTO t (a) VALUES :items(:product_id)",
+			values:        map[string]any{"items": mapItems[0]},
+			expectedError: sqlparams.ErrInvalidSpreadValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.dialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_RowTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           sqlparams.SQLQuery
+		expectedSQL   sqlparams.SQLQuery
+		expectedError error
+	}{
+		{
+			name:        "renders a single row",
+			sql:         "INSERT INTO t (a, b) VALUES :items(:a, :b)",
+			expectedSQL: "INSERT INTO t (a, b) VALUES ($1, $2)",
+		},
+		{
+			name:          "unclosed",
+			sql:           "INSERT INTO t (a, b) VALUES :items(:a, :b",
+			expectedError: sqlparams.ErrInvalidRowTemplate,
+		},
+		{
+			name:          "nested",
+			sql:           "INSERT INTO t (a) VALUES :items(:sub(:a))",
+			expectedError: sqlparams.ErrInvalidRowTemplate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, parsed.SQL)
+			}
+			for _, p := range parsed.Parameters() {
+				if p.Parent != "items" {
+					t.Errorf("expected %v to have Parent items", p)
+				}
+			}
+		})
+	}
+}
+
+func TestParsedSQL_BindBatches(t *testing.T) {
+	items := make([]map[string]any, 5)
+	for i := range items {
+		items[i] = map[string]any{"a": i, "b": i * 10}
+	}
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"INSERT INTO t (a, b) VALUES :items(:a, :b)",
+		sqlparams.ParseSQLArgs{MaxParams: 4},
+	)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	values := map[string]any{"items": items}
+
+	_, err = parsed.Bind(values)
+	if !errors.Is(err, sqlparams.ErrTooManyParameters) {
+		t.Fatalf("expected %v from Bind, got %v", sqlparams.ErrTooManyParameters, err)
+	}
+
+	batches, err := parsed.BindBatches(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []sqlparams.BoundSQL{
+		{SQL: "INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)", Args: []any{0, 0, 1, 10}},
+		{SQL: "INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)", Args: []any{2, 20, 3, 30}},
+		{SQL: "INSERT INTO t (a, b) VALUES ($1, $2)", Args: []any{4, 40}},
+	}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Errorf("batches mismatch:\nexpected: %#v\nactual:   %#v", expected, batches)
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestDB_QueryRows(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	fake.results["SELECT id FROM none"] = fakeResult{columns: []string{"id"}}
+	fake.results["SELECT id FROM one"] = fakeResult{columns: []string{"id"}, rows: [][]any{{int64(1)}}}
+	fake.results["SELECT id FROM many"] = fakeResult{
+		columns: []string{"id"},
+		rows:    [][]any{{int64(1)}, {int64(2)}, {int64(3)}, {int64(4)}},
+	}
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+
+	tests := []struct {
+		name          string
+		cardinality   sqlparams.Cardinality
+		table         string
+		expectedCount int
+		expectedRead  int
+		expectedError error
+	}{
+		{name: "one", cardinality: sqlparams.OneRow, table: "one", expectedCount: 1, expectedRead: 1},
+		{name: "one of none", cardinality: sqlparams.OneRow, table: "none", expectedError: sqlparams.ErrEmptyResult},
+		{name: "one of many stops at the second row", cardinality: sqlparams.OneRow, table: "many", expectedCount: 1, expectedRead: 2, expectedError: sqlparams.ErrTooManyRows},
+		{name: "one? of none", cardinality: sqlparams.OneRowOrNone, table: "none"},
+		{name: "one? of many", cardinality: sqlparams.OneRowOrNone, table: "many", expectedCount: 1, expectedRead: 2, expectedError: sqlparams.ErrTooManyRows},
+		{name: "many", cardinality: sqlparams.ManyRows, table: "many", expectedCount: 4, expectedRead: 4},
+		{name: "many of none", cardinality: sqlparams.ManyRows, table: "none", expectedError: sql.ErrNoRows},
+		{name: "many? of none", cardinality: sqlparams.ManyRowsOrNone, table: "none"},
+		{name: "invalid", cardinality: "some", table: "one", expectedError: sqlparams.ErrInvalidCardinalityType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.rowsRead = 0
+			var ids []int64
+			n, err := db.QueryRows(context.Background(), tt.cardinality, sqlparams.SQLQuery("SELECT id FROM "+tt.table), nil,
+				func(rows *sql.Rows) error {
+					var id int64
+					err := rows.Scan(&id)
+					ids = append(ids, id)
+					return err
+				})
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if n != tt.expectedCount || len(ids) != tt.expectedCount {
+				t.Errorf("expected %d rows scanned, got %d (%v)", tt.expectedCount, n, ids)
+			}
+			if fake.rowsRead != tt.expectedRead {
+				t.Errorf("expected %d rows read, got %d", tt.expectedRead, fake.rowsRead)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindSpread(t *testing.T) {
+	tests := []struct {
+		name          string
+		dialect       sqlparams.Dialect
+		sql           sqlparams.SQLQuery
+		args          sqlparams.ParseSQLArgs
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "postgres renumbers later placeholders",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE org = :org AND id IN (:ids...) AND owner = :org AND kind = :kind",
+			values:       map[string]any{"org": 9, "ids": []int{1, 2, 3}, "kind": "x"},
+			expectedSQL:  "SELECT * FROM t WHERE org = $1 AND id IN ($2, $3, $4) AND owner = $1 AND kind = $5",
+			expectedArgs: []any{9, 1, 2, 3, "x"},
+		},
+		{
+			name:         "repeated spread reuses its placeholders",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE a IN (:ids...) OR b IN (:ids...)",
+			values:       map[string]any{"ids": []string{"x", "y"}},
+			expectedSQL:  "SELECT * FROM t WHERE a IN ($1, $2) OR b IN ($1, $2)",
+			expectedArgs: []any{"x", "y"},
+		},
+		{
+			name:         "mysql flattens per occurrence",
+			dialect:      sqlparams.MySQLDialect,
+			sql:          "SELECT * FROM t WHERE a IN (:ids...) AND b = :b OR c IN (:ids...)",
+			values:       map[string]any{"ids": []any{1, 2}, "b": true},
+			expectedSQL:  "SELECT * FROM t WHERE a IN (?, ?) AND b = ? OR c IN (?, ?)",
+			expectedArgs: []any{1, 2, true, 1, 2},
+		},
+		{
+			name:        "named dialect names each element",
+			dialect:     sqlparams.SQLServerNamedDialect,
+			sql:         "SELECT * FROM t WHERE id IN (:ids...)",
+			values:      map[string]any{"ids": [2]int64{5, 6}},
+			expectedSQL: "SELECT * FROM t WHERE id IN (@ids_0, @ids_1)",
+			expectedArgs: []any{
+				sql.Named("ids_0", int64(5)),
+				sql.Named("ids_1", int64(6)),
+			},
+		},
+		{
+			name:          "named dialect rejects element name collisions",
+			dialect:       sqlparams.SQLServerNamedDialect,
+			sql:           "SELECT * FROM t WHERE id IN (:ids...) AND other = :ids_0",
+			values:        map[string]any{"ids": []int{1, 2}, "ids_0": 2},
+			expectedError: sqlparams.ErrNativeNameCollision,
+		},
+		{
+			name:          "empty slice fails by default",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE id IN (:ids...)",
+			values:        map[string]any{"ids": []int{}},
+			expectedError: sqlparams.ErrEmptySpreadValue,
+		},
+		{
+			name:         "empty slice binds NULL when configured",
+			dialect:      sqlparams.PostgresDialect,
+			sql:          "SELECT * FROM t WHERE id IN (:ids...) AND a = :a",
+			args:         sqlparams.ParseSQLArgs{EmptySlice: sqlparams.NullOnEmptySlice},
+			values:       map[string]any{"ids": []int(nil), "a": 1},
+			expectedSQL:  "SELECT * FROM t WHERE id IN ($1) AND a = $2",
+			expectedArgs: []any{nil, 1},
+		},
+		{
+			name:          "non-slice value",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE id IN (:ids...)",
+			values:        map[string]any{"ids": 42},
+			expectedError: sqlparams.ErrInvalidSpreadValue,
+		},
+		{
+			name:          "byte slice is not spread",
+			dialect:       sqlparams.PostgresDialect,
+			sql:           "SELECT * FROM t WHERE id IN (:ids...)",
+			values:        map[string]any{"ids": []byte("ab")},
+			expectedError: sqlparams.ErrInvalidSpreadValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.dialect.ParseSQL(tt.sql, tt.args)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParseSQL_Spread(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL("SELECT * FROM t WHERE id IN (:ids...) AND a = :a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.SQL != "SELECT * FROM t WHERE id IN ($1) AND a = $2" {
+		t.Errorf("SQL mismatch: got %q", parsed.SQL)
+	}
+	params := parsed.Parameters()
+	if len(params) != 2 || params[0].Name != "ids" || !params[0].Spread || params[1].Spread {
+		t.Errorf("Parameters mismatch: got %#v", params)
+	}
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestStmtCache(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	cache := sqlparams.NewStmtCache(sqlDB, 2)
+	ctx := context.Background()
+	exec := func(query string) {
+		t.Helper()
+		_, err := cache.ExecContext(ctx, query, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if fake.prepared != 0 {
+		t.Fatalf("expected statements to be prepared lazily")
+	}
+	exec("DELETE FROM a WHERE id = $1")
+	exec("DELETE FROM a WHERE id = $1")
+	if fake.prepared != 1 {
+		t.Errorf("expected 1 prepare, got %d", fake.prepared)
+	}
+
+	exec("DELETE FROM b WHERE id = $1")
+	exec("DELETE FROM c WHERE id = $1") // evicts a
+	if fake.closed != 1 {
+		t.Errorf("expected the evicted statement to be closed, got %d closed", fake.closed)
+	}
+	expected := sqlparams.StmtCacheStats{Hits: 1, Misses: 3, Evictions: 1, Len: 2, Size: 2}
+	if cache.Stats() != expected {
+		t.Errorf("Stats mismatch:\nexpected: %+v\nactual:   %+v", expected, cache.Stats())
+	}
+
+	err := cache.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.closed != 3 || cache.Stats().Len != 0 {
+		t.Errorf("expected Close to close every statement, got %d closed", fake.closed)
+	}
+}
+
+func TestStmtCache_BadConn(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	cache := sqlparams.NewStmtCache(sqlDB, 2)
+	ctx := context.Background()
+
+	_, err := cache.ExecContext(ctx, "DELETE FROM a WHERE id = $1", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Enough failures to outlast database/sql's own retries
+	fake.badConns = 3
+	_, err = cache.ExecContext(ctx, "DELETE FROM a WHERE id = $1", 2)
+	if err != nil {
+		t.Fatalf("expected a transparent re-prepare, got %v", err)
+	}
+	if cache.Stats().Reprepares != 1 {
+		t.Errorf("expected 1 re-prepare, got %+v", cache.Stats())
+	}
+	if fake.lastArgs()[0] != int64(2) {
+		t.Errorf("expected the call to be retried, got %v", fake.lastArgs())
+	}
+}
+
+func TestDB_StmtCache(t *testing.T) {
+	sqlDB, fake := newFakeDB(t)
+	fake.results["SELECT name FROM users WHERE id = $1"] = fakeResult{
+		columns: []string{"name"},
+		rows:    [][]any{{"ann"}},
+	}
+	db := sqlparams.NewDB(sqlDB, sqlparams.PostgresDialect)
+	db = db.WithStmtCache(sqlparams.NewStmtCache(db.DB(), 4))
+	ctx := context.Background()
+
+	for range 3 {
+		var name string
+		err := db.QueryRowNamed(ctx, "SELECT name FROM users WHERE id = :id", map[string]any{"id": 1}).Scan(&name)
+		if err != nil || name != "ann" {
+			t.Fatalf("unexpected result %q, %v", name, err)
+		}
+	}
+	rows, err := db.QueryNamed(ctx, "SELECT name FROM users WHERE id = :id", map[string]any{"id": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = rows.Close()
+	stats := db.StmtCache().Stats()
+	if stats.Misses != 1 || stats.Hits != 3 || fake.prepared != 1 {
+		t.Errorf("expected one prepare, got %d and %+v", fake.prepared, stats)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = tx.ExecNamed(ctx, "DELETE FROM t WHERE id = :id", map[string]any{"id": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = tx.Commit()
+	if db.StmtCache().Stats().Misses != 1 {
+		t.Errorf("expected transactions to run unprepared")
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.closed != 1 {
+		t.Errorf("expected Close to close the cached statement, got %d closed", fake.closed)
+	}
+	err = db.DB().PingContext(ctx)
+	if err != nil {
+		t.Errorf("expected Close to leave the caller's *sql.DB open, got %v", err)
+	}
+}
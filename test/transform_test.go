@@ -0,0 +1,171 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-sqlparams"
+)
+
+func TestParsedSQL_BindTransforms(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           sqlparams.SQLQuery
+		values        map[string]any
+		expectedSQL   sqlparams.SQLQuery
+		expectedArgs  []any
+		expectedError error
+	}{
+		{
+			name:         "chained in order",
+			sql:          "SELECT * FROM users WHERE email = :email:string|trim|lower",
+			values:       map[string]any{"email": "  Ann@Example.COM "},
+			expectedSQL:  "SELECT * FROM users WHERE email = $1",
+			expectedArgs: []any{"ann@example.com"},
+		},
+		{
+			name:         "like_prefix escapes wildcards",
+			sql:          "SELECT * FROM t WHERE name LIKE :q:string|like_prefix",
+			values:       map[string]any{"q": `50%_off\`},
+			expectedSQL:  "SELECT * FROM t WHERE name LIKE $1",
+			expectedArgs: []any{`50\%\_off\\%`},
+		},
+		{
+			name:         "before data type and shared by occurrences",
+			sql:          "SELECT * FROM t WHERE (:status:string?|null_if_empty IS NULL OR status = :status)",
+			values:       map[string]any{"status": " "},
+			expectedSQL:  "SELECT * FROM t WHERE ($1 IS NULL OR status = $1)",
+			expectedArgs: []any{nil},
+		},
+		{
+			name:         "each spread element",
+			sql:          "SELECT * FROM t WHERE code IN (:codes...:string|upper)",
+			values:       map[string]any{"codes": []string{"a", "b"}},
+			expectedSQL:  "SELECT * FROM t WHERE code IN ($1, $2)",
+			expectedArgs: []any{"A", "B"},
+		},
+		{
+			name:         "without a type annotation '|' is SQL",
+			sql:          "SELECT :a||'%', :flags|mask, :bits|lower, :b:string||'%'",
+			values:       map[string]any{"a": "x", "flags": 1, "bits": 2, "b": "y"},
+			expectedSQL:  "SELECT $1||'%', $2|mask, $3|lower, $4||'%'",
+			expectedArgs: []any{"x", 1, 2, "y"},
+		},
+		{
+			name:          "non-string value",
+			sql:           "SELECT * FROM t WHERE name = :name:any|lower",
+			values:        map[string]any{"name": 42},
+			expectedError: sqlparams.ErrTransformFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := sqlparams.PostgresDialect.ParseSQL(tt.sql)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			bound, err := parsed.Bind(tt.values)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected bind error: %v", err)
+			}
+			if bound.SQL != tt.expectedSQL {
+				t.Errorf("SQL mismatch:\nexpected: %q\nactual:   %q", tt.expectedSQL, bound.SQL)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.expectedArgs) {
+				t.Errorf("Args mismatch:\nexpected: %#v\nactual:   %#v", tt.expectedArgs, bound.Args)
+			}
+		})
+	}
+}
+
+func TestParsedSQL_BindTransformFailure(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"SELECT * FROM t WHERE a = :a:string|trim",
+		sqlparams.ParseSQLArgs{Constraints: map[sqlparams.Selector]sqlparams.Constraint{
+			"a": {Enum: []any{"x"}},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	_, err = parsed.Bind(map[string]any{"a": 5})
+	if !errors.Is(err, sqlparams.ErrTransformFailed) {
+		t.Fatalf("expected %v, got %v", sqlparams.ErrTransformFailed, err)
+	}
+	for _, unexpected := range []error{sqlparams.ErrInvalidDataType, sqlparams.ErrConstraintViolation} {
+		if errors.Is(err, unexpected) {
+			t.Errorf("expected only %v, got %v", sqlparams.ErrTransformFailed, err)
+		}
+	}
+}
+
+func TestParseSQL_Transforms(t *testing.T) {
+	parsed, err := sqlparams.PostgresDialect.ParseSQL(
+		"SELECT * FROM users WHERE email = :email:string|trim|lower OR alt = :email",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := sqlparams.Parameters{
+		{Name: "email", Index: 1, DataType: sqlparams.StringDBDataType, Transforms: "trim|lower"},
+	}
+	if !reflect.DeepEqual(parsed.Parameters(), expected) {
+		t.Errorf("Parameters mismatch:\nexpected: %#v\nactual:   %#v", expected, parsed.Parameters())
+	}
+	names := parsed.Parameters()[0].Transforms.Names()
+	if !reflect.DeepEqual(names, []string{"trim", "lower"}) {
+		t.Errorf("expected [trim lower], got %v", names)
+	}
+
+	_, err = sqlparams.PostgresDialect.ParseSQL("SELECT :email:string|lower, :email:string|upper")
+	if !errors.Is(err, sqlparams.ErrConflictingTransforms) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrConflictingTransforms, err)
+	}
+
+	_, err = sqlparams.PostgresDialect.ParseSQL("SELECT :flags:integer|mask")
+	if !errors.Is(err, sqlparams.ErrInvalidTransform) {
+		t.Errorf("expected %v, got %v", sqlparams.ErrInvalidTransform, err)
+	}
+}
+
+func TestRegisterTransform(t *testing.T) {
+	err := sqlparams.RegisterTransform("test_reverse", func(value any) (any, error) {
+		r := []rune(value.(string))
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := sqlparams.PostgresDialect.ParseSQL("SELECT :s:string|test_reverse|upper")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	bound, err := parsed.Bind(map[string]any{"s": "abc"})
+	if err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+	if !reflect.DeepEqual(bound.Args, []any{"CBA"}) {
+		t.Errorf("expected [CBA], got %#v", bound.Args)
+	}
+
+	for _, name := range []string{"test_reverse", "lower", "not-valid", ""} {
+		err = sqlparams.RegisterTransform(name, func(value any) (any, error) {
+			return strings.ToLower(value.(string)), nil
+		})
+		if !errors.Is(err, sqlparams.ErrInvalidTransform) {
+			t.Errorf("%q: expected %v, got %v", name, sqlparams.ErrInvalidTransform, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package sqlparams
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TransformFunc pre-processes a parameter's value at bind time, before it is
+// coerced to the parameter's data type and checked against its constraints.
+type TransformFunc func(value any) (any, error)
+
+// TransformChain is a '|'-separated list of transform names, as written
+// after a placeholder's type, e.g. "lower|trim" for :email:string|lower|trim.
+type TransformChain string
+
+// Names returns the transform names in the order they are applied.
+func (tc TransformChain) Names() (names []string) {
+	if tc != "" {
+		names = strings.Split(string(tc), "|")
+	}
+	return names
+}
+
+func (tc TransformChain) append(name string) TransformChain {
+	if tc == "" {
+		return TransformChain(name)
+	}
+	return tc + "|" + TransformChain(name)
+}
+
+// transforms holds the built-in and registered transforms by name.
+var transforms = struct {
+	sync.RWMutex
+	funcs map[string]TransformFunc
+}{
+	funcs: map[string]TransformFunc{
+		"lower":         stringTransform(strings.ToLower),
+		"upper":         stringTransform(strings.ToUpper),
+		"trim":          stringTransform(strings.TrimSpace),
+		"null_if_empty": nullIfEmpty,
+		"like_escape":   stringTransform(escapeLike),
+		"like_prefix":   stringTransform(func(s string) string { return escapeLike(s) + "%" }),
+		"like_suffix":   stringTransform(func(s string) string { return "%" + escapeLike(s) }),
+		"like_contains": stringTransform(func(s string) string { return "%" + escapeLike(s) + "%" }),
+	},
+}
+
+// RegisterTransform makes fn available to placeholders as
+// :name:type|transform.
+// Names follow the rules for identifiers and cannot be registered twice,
+// including the built-in lower, upper, trim, null_if_empty, like_escape,
+// like_prefix, like_suffix and like_contains, and sensitive, which marks a
+// parameter sensitive rather than transforming it. Register transforms before
+// parsing the templates that use them; parsing fails with ErrInvalidTransform
+// on an unknown name.
+func RegisterTransform(name string, fn TransformFunc) (err error) {
+	var i int
+
+	transforms.Lock()
+	defer transforms.Unlock()
+	if fn == nil || !readIdent(name, &i) || i != len(name) {
+		err = NewErr(ErrInvalidTransform, "transform", name, "reason", "invalid name or nil func")
+		goto end
+	}
+	if _, ok := transforms.funcs[name]; ok || name == sensitiveAnnotation {
+		err = NewErr(ErrInvalidTransform, "transform", name, "reason", "already registered")
+		goto end
+	}
+	transforms.funcs[name] = fn
+end:
+	return err
+}
+
+func lookupTransform(name string) (fn TransformFunc, ok bool) {
+	transforms.RLock()
+	defer transforms.RUnlock()
+	fn, ok = transforms.funcs[name]
+	return fn, ok
+}
+
+// stringTransform adapts a string function to a TransformFunc that passes
+// NULL through and rejects values that are not strings.
+func stringTransform(fn func(string) string) TransformFunc {
+	return func(value any) (out any, err error) {
+		rv := indirectValue(reflect.ValueOf(value))
+		switch {
+		case !rv.IsValid():
+		case rv.Kind() == reflect.String:
+			out = fn(rv.String())
+		default:
+			err = fmt.Errorf("%T is not a string", value)
+		}
+		return out, err
+	}
+}
+
+// nullIfEmpty turns an empty or all-space string into NULL, e.g. for
+// optional filters submitted from HTML forms.
+func nullIfEmpty(value any) (out any, err error) {
+	out = value
+	rv := indirectValue(reflect.ValueOf(value))
+	if rv.IsValid() && rv.Kind() == reflect.String && strings.TrimSpace(rv.String()) == "" {
+		out = nil
+	}
+	return out, err
+}
+
+// likeEscaper escapes LIKE wildcards with a backslash, the default escape
+// character for PostgreSQL and MySQL; SQLite needs ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}